@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+)
+
+// defaultBatchConcurrency bounds the number of concurrent requests used by
+// backends without native batch operations.
+const defaultBatchConcurrency = 16
+
+// Result is the outcome of reading a single key in a batch.
+type Result struct {
+	Value interface{}
+	Err   error
+}
+
+// Entry is a key and value written in a batch.
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// Batcher is implemented by caches that can read and write several keys at once.
+// Results and errors are returned per key, in the order of the keys or entries passed in.
+type Batcher interface {
+	GetMany(ctx context.Context, keys []string) []Result
+	SetMany(ctx context.Context, entries []Entry, options *store.Options) []error
+	DeleteMany(ctx context.Context, keys []string) []error
+}
+
+// GetMany reads keys from c, in a single batch if c implements Batcher.
+func GetMany(ctx context.Context, c cache.CacheInterface, keys []string) []Result {
+	if b, ok := c.(Batcher); ok {
+		return b.GetMany(ctx, keys)
+	}
+	return getEach(ctx, c, keys)
+}
+
+// SetMany writes entries to c, in a single batch if c implements Batcher.
+func SetMany(ctx context.Context, c cache.CacheInterface, entries []Entry, options *store.Options) []error {
+	if b, ok := c.(Batcher); ok {
+		return b.SetMany(ctx, entries, options)
+	}
+	return setEach(ctx, c, entries, options)
+}
+
+// DeleteMany removes keys from c, in a single batch if c implements Batcher.
+func DeleteMany(ctx context.Context, c cache.CacheInterface, keys []string) []error {
+	if b, ok := c.(Batcher); ok {
+		return b.DeleteMany(ctx, keys)
+	}
+	return deleteEach(ctx, c, keys)
+}
+
+// GetMany reads keys using the backend's batch operations if it has any.
+// Misses are reported as ErrNotFound or ErrKnownAbsent, as with Get.
+func (c *Cache) GetMany(ctx context.Context, keys []string) []Result {
+	results := GetMany(ctx, c.CacheInterface, keys)
+	for i, result := range results {
+		results[i].Value, results[i].Err = c.result(result.Value, result.Err)
+	}
+	return results
+}
+
+// SetMany writes entries using the backend's batch operations if it has any.
+func (c *Cache) SetMany(ctx context.Context, entries []Entry, options *store.Options) []error {
+	return SetMany(ctx, c.CacheInterface, entries, options)
+}
+
+// DeleteMany removes keys using the backend's batch operations if it has any.
+func (c *Cache) DeleteMany(ctx context.Context, keys []string) []error {
+	return DeleteMany(ctx, c.CacheInterface, keys)
+}
+
+func getEach(ctx context.Context, c cache.CacheInterface, keys []string) []Result {
+	results := make([]Result, len(keys))
+	for i, key := range keys {
+		results[i].Value, results[i].Err = c.Get(ctx, key)
+	}
+	return results
+}
+
+func setEach(ctx context.Context, c cache.CacheInterface, entries []Entry, options *store.Options) []error {
+	errs := make([]error, len(entries))
+	for i, entry := range entries {
+		errs[i] = c.Set(ctx, entry.Key, entry.Value, options)
+	}
+	return errs
+}
+
+func deleteEach(ctx context.Context, c cache.CacheInterface, keys []string) []error {
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		errs[i] = c.Delete(ctx, key)
+	}
+	return errs
+}
+
+// fanOut calls f for every index in [0, n), running at most limit calls at a time.
+func fanOut(n, limit int, f func(i int)) {
+	if limit <= 0 {
+		limit = defaultBatchConcurrency
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
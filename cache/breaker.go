@@ -0,0 +1,340 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// CircuitBreakerType is the type returned by CircuitBreaker.GetType.
+	CircuitBreakerType = "circuit-breaker"
+
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 10 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// ErrCircuitOpen is returned by writes to a cache whose circuit breaker is open
+// when there's no fallback cache to write to instead, and by deletes regardless,
+// since the entries they remove are left in the backend.
+var ErrCircuitOpen = errors.New("cache: circuit breaker is open")
+
+// CircuitBreakerConfig configures the circuit breaker NewCache puts in front of
+// remote backends (redis, memcache and NATS).
+type CircuitBreakerConfig struct {
+	Enabled bool `json:"enabled"`
+	// FailureThreshold is the number of consecutive backend failures that opens the circuit. Defaults to 5.
+	FailureThreshold int `json:"failure_threshold"`
+	// OpenTimeout is how long the circuit stays open before probing the backend again. Defaults to 10 seconds.
+	OpenTimeout time.Duration `json:"open_timeout"`
+	// HalfOpenRequests is the number of concurrent requests let through to probe the backend. Defaults to 1.
+	HalfOpenRequests int `json:"half_open_requests"`
+	// FallbackSize is the size in bytes of an in-process freecache that keeps a copy of
+	// the values written and serves them while the circuit is open. No fallback is used if 0.
+	FallbackSize int `json:"fallback_size"`
+}
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+var circuitStateToString = map[CircuitState]string{
+	CircuitClosed:   "closed",
+	CircuitOpen:     "open",
+	CircuitHalfOpen: "half-open",
+}
+
+func (s CircuitState) String() string {
+	return circuitStateToString[s]
+}
+
+// CircuitBreaker is a cache.CacheInterface that stops calling a failing backend.
+// While the circuit is open, reads are served from the fallback cache or reported as
+// ErrNotFound, and writes go to the fallback cache only. Deletes fail with ErrCircuitOpen,
+// so callers know the backend may still serve the entries once the circuit closes.
+type CircuitBreaker struct {
+	cache    cache.CacheInterface
+	fallback cache.CacheInterface
+	cfg      CircuitBreakerConfig
+	logger   *zerolog.Logger
+	now      func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// NewCircuitBreaker wraps c with a circuit breaker. fallback may be nil.
+func NewCircuitBreaker(logger *zerolog.Logger, c, fallback cache.CacheInterface, cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultFailureThreshold
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaultHalfOpenRequests
+	}
+
+	breakerLogger := logger.With().Str("component", "cache-circuit-breaker").Str("backend", c.GetType()).Logger()
+
+	return &CircuitBreaker{
+		cache:    c,
+		fallback: fallback,
+		cfg:      cfg,
+		logger:   &breakerLogger,
+		now:      time.Now,
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *CircuitBreaker) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	if !b.allow() {
+		if b.fallback == nil {
+			return nil, ErrNotFound
+		}
+		return b.fallback.Get(ctx, key)
+	}
+
+	value, err := b.cache.Get(ctx, key)
+	b.record(err)
+	return value, err
+}
+
+func (b *CircuitBreaker) Set(ctx context.Context, key, object interface{}, options *store.Options) error {
+	b.setFallback(ctx, key, object, options)
+
+	if !b.allow() {
+		if b.fallback == nil {
+			return ErrCircuitOpen
+		}
+		return nil
+	}
+
+	err := b.cache.Set(ctx, key, object, options)
+	b.record(err)
+	return err
+}
+
+func (b *CircuitBreaker) Delete(ctx context.Context, key interface{}) error {
+	if b.fallback != nil {
+		_ = b.fallback.Delete(ctx, key)
+	}
+
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := b.cache.Delete(ctx, key)
+	b.record(err)
+	return err
+}
+
+func (b *CircuitBreaker) Invalidate(ctx context.Context, options store.InvalidateOptions) error {
+	if b.fallback != nil {
+		_ = b.fallback.Invalidate(ctx, options)
+	}
+
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := b.cache.Invalidate(ctx, options)
+	b.record(err)
+	return err
+}
+
+func (b *CircuitBreaker) Clear(ctx context.Context) error {
+	if b.fallback != nil {
+		_ = b.fallback.Clear(ctx)
+	}
+
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := b.cache.Clear(ctx)
+	b.record(err)
+	return err
+}
+
+// Close closes the backend and the fallback cache if they have a Close method.
+func (b *CircuitBreaker) Close() {
+	closeCache(b.cache)
+	if b.fallback != nil {
+		closeCache(b.fallback)
+	}
+}
+
+func (b *CircuitBreaker) GetType() string {
+	return CircuitBreakerType
+}
+
+// GetMany reads keys with the backend's batch operations, as a single request to the breaker.
+func (b *CircuitBreaker) GetMany(ctx context.Context, keys []string) []Result {
+	if !b.allow() {
+		if b.fallback == nil {
+			results := make([]Result, len(keys))
+			for i := range results {
+				results[i].Err = ErrNotFound
+			}
+			return results
+		}
+		return GetMany(ctx, b.fallback, keys)
+	}
+
+	results := GetMany(ctx, b.cache, keys)
+	errs := make([]error, len(results))
+	for i, result := range results {
+		errs[i] = result.Err
+	}
+	b.record(firstFailure(errs))
+	return results
+}
+
+// SetMany writes entries with the backend's batch operations, as a single request to the breaker.
+func (b *CircuitBreaker) SetMany(ctx context.Context, entries []Entry, options *store.Options) []error {
+	for _, entry := range entries {
+		b.setFallback(ctx, entry.Key, entry.Value, options)
+	}
+
+	if !b.allow() {
+		return b.openErrors(len(entries))
+	}
+
+	errs := SetMany(ctx, b.cache, entries, options)
+	b.record(firstFailure(errs))
+	return errs
+}
+
+// DeleteMany removes keys with the backend's batch operations, as a single request to the breaker.
+func (b *CircuitBreaker) DeleteMany(ctx context.Context, keys []string) []error {
+	if b.fallback != nil {
+		DeleteMany(ctx, b.fallback, keys)
+	}
+
+	if !b.allow() {
+		errs := make([]error, len(keys))
+		for i := range errs {
+			errs[i] = ErrCircuitOpen
+		}
+		return errs
+	}
+
+	errs := DeleteMany(ctx, b.cache, keys)
+	b.record(firstFailure(errs))
+	return errs
+}
+
+// openErrors returns the errors of a batch write rejected because the circuit is open.
+func (b *CircuitBreaker) openErrors(n int) []error {
+	errs := make([]error, n)
+	if b.fallback == nil {
+		for i := range errs {
+			errs[i] = ErrCircuitOpen
+		}
+	}
+	return errs
+}
+
+func (b *CircuitBreaker) setFallback(ctx context.Context, key, object interface{}, options *store.Options) {
+	if b.fallback == nil {
+		return
+	}
+
+	if err := b.fallback.Set(ctx, key, object, options); err != nil {
+		b.logger.Trace().Err(err).Msgf("failed to write %v to fallback cache", key)
+	}
+}
+
+// allow reports whether a request may go through to the backend.
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+		b.probes = 1
+		return true
+	case CircuitHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return false
+		}
+		b.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the circuit with the outcome of a request that went through to the backend.
+// Misses and cancellations by the caller don't count as backend failures.
+func (b *CircuitBreaker) record(err error) {
+	failed := err != nil && !isNotFound(err) && !errors.Is(err, context.Canceled)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		if b.state == CircuitHalfOpen {
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.logger.Debug().Err(err).Int("failures", b.failures).Msg("cache backend request failed")
+		b.openedAt = b.now()
+		b.setState(CircuitOpen)
+	}
+}
+
+// setState changes the state of the circuit. The caller must hold b.mu.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+
+	event := b.logger.Info()
+	if state == CircuitOpen {
+		event = b.logger.Warn()
+	}
+	event.Str("from", b.state.String()).Str("to", state.String()).Msg("cache circuit breaker state changed")
+
+	b.state = state
+	b.probes = 0
+}
+
+func firstFailure(errs []error) error {
+	for _, err := range errs {
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
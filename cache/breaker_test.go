@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coocood/freecache"
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackendDown = errors.New("connection refused")
+
+// flakyCache is a backend that fails every request while down is set.
+type flakyCache struct {
+	cache.CacheInterface
+	down  bool
+	calls int
+}
+
+func (f *flakyCache) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	f.calls++
+	if f.down {
+		return nil, errBackendDown
+	}
+	return f.CacheInterface.Get(ctx, key)
+}
+
+func (f *flakyCache) Set(ctx context.Context, key, object interface{}, options *store.Options) error {
+	f.calls++
+	if f.down {
+		return errBackendDown
+	}
+	return f.CacheInterface.Set(ctx, key, object, options)
+}
+
+func newFreecache() cache.CacheInterface {
+	return cache.New(store.NewFreecache(freecache.NewCache(1024*1024), &store.Options{Expiration: time.Minute}))
+}
+
+func newTestBreaker(backend, fallback cache.CacheInterface, now *time.Time) *CircuitBreaker {
+	b := NewCircuitBreaker(&zerolog.Logger{}, backend, fallback, CircuitBreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 3,
+		OpenTimeout:      10 * time.Second,
+	})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestCircuitBreakerOpensAndFailsFast(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	backend := &flakyCache{CacheInterface: newFreecache(), down: true}
+	b := newTestBreaker(backend, nil, &now)
+
+	for i := 0; i < 3; i++ {
+		_, err := b.Get(context.Background(), "key")
+		assert.ErrorIs(err, errBackendDown)
+	}
+	assert.Equal(CircuitOpen, b.State())
+
+	_, err := b.Get(context.Background(), "key")
+	assert.ErrorIs(err, ErrNotFound)
+	assert.ErrorIs(b.Set(context.Background(), "key", []byte("value"), nil), ErrCircuitOpen)
+	assert.Equal(3, backend.calls)
+}
+
+func TestCircuitBreakerMissesDontCount(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	b := newTestBreaker(&flakyCache{CacheInterface: newFreecache()}, nil, &now)
+
+	for i := 0; i < 5; i++ {
+		_, err := b.Get(context.Background(), "missing")
+		assert.Error(err)
+	}
+	assert.Equal(CircuitClosed, b.State())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	backend := &flakyCache{CacheInterface: newFreecache(), down: true}
+	b := newTestBreaker(backend, nil, &now)
+
+	for i := 0; i < 3; i++ {
+		_, _ = b.Get(context.Background(), "key")
+	}
+	assert.Equal(CircuitOpen, b.State())
+
+	// A failed probe opens the circuit again.
+	now = now.Add(11 * time.Second)
+	_, err := b.Get(context.Background(), "key")
+	assert.ErrorIs(err, errBackendDown)
+	assert.Equal(CircuitOpen, b.State())
+
+	// A successful probe closes it.
+	backend.down = false
+	now = now.Add(11 * time.Second)
+	assert.NoError(b.Set(context.Background(), "key", []byte("value"), nil))
+	assert.Equal(CircuitClosed, b.State())
+
+	value, err := b.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), value)
+}
+
+func TestCircuitBreakerFallback(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	backend := &flakyCache{CacheInterface: newFreecache()}
+	b := newTestBreaker(backend, newFreecache(), &now)
+
+	assert.NoError(b.Set(context.Background(), "key", []byte("value"), nil))
+
+	backend.down = true
+	for i := 0; i < 3; i++ {
+		_, _ = b.Get(context.Background(), "key")
+	}
+	assert.Equal(CircuitOpen, b.State())
+
+	value, err := b.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), value)
+
+	assert.NoError(b.Set(context.Background(), "other", []byte("other"), nil))
+	value, err = b.Get(context.Background(), "other")
+	assert.NoError(err)
+	assert.Equal([]byte("other"), value)
+}
+
+func TestCircuitBreakerDeleteWhileOpen(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	backend := &flakyCache{CacheInterface: newFreecache()}
+	b := newTestBreaker(backend, newFreecache(), &now)
+
+	assert.NoError(b.Set(context.Background(), "key", []byte("value"), nil))
+	assert.NoError(b.Set(context.Background(), "other", []byte("other"), nil))
+
+	backend.down = true
+	for i := 0; i < 3; i++ {
+		_, _ = b.Get(context.Background(), "key")
+	}
+	assert.Equal(CircuitOpen, b.State())
+
+	// The fallback drops the entries, but the backend still has them.
+	assert.ErrorIs(b.Delete(context.Background(), "key"), ErrCircuitOpen)
+	for _, err := range b.DeleteMany(context.Background(), []string{"other"}) {
+		assert.ErrorIs(err, ErrCircuitOpen)
+	}
+	_, err := b.Get(context.Background(), "key")
+	assert.Error(err)
+
+	backend.down = false
+	now = now.Add(11 * time.Second)
+	value, err := b.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), value)
+	assert.Equal(CircuitClosed, b.State())
+
+	// Once the circuit closes, deleting again removes the entries from the backend.
+	assert.NoError(b.Delete(context.Background(), "key"))
+	for _, err := range b.DeleteMany(context.Background(), []string{"other"}) {
+		assert.NoError(err)
+	}
+	_, err = b.Get(context.Background(), "key")
+	assert.Error(err)
+	_, err = b.Get(context.Background(), "other")
+	assert.Error(err)
+}
+
+// closingCache records whether it was closed.
+type closingCache struct {
+	cache.CacheInterface
+	closed bool
+}
+
+func (c *closingCache) Close() {
+	c.closed = true
+}
+
+func TestCircuitBreakerClose(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	backend := &closingCache{CacheInterface: newFreecache()}
+	fallback := &closingCache{CacheInterface: newFreecache()}
+
+	newTestBreaker(backend, fallback, &now).Close()
+	assert.True(backend.closed)
+	assert.True(fallback.closed)
+
+	// Backends without a Close method are left alone.
+	newTestBreaker(newFreecache(), nil, &now).Close()
+}
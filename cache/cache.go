@@ -2,6 +2,7 @@ package cache
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,12 +34,50 @@ type CacheConfig struct {
 	FreeCacheSize   int             `json:"freecache_size"`
 	NatsJSConfig    NatsConfig      `json:"nats_js_config"`
 	TTL             time.Duration   `json:"ttl"`
+	// NegativeTTL is how long keys recorded with SetNotFound are known to be absent.
+	// Defaults to TTL.
+	NegativeTTL time.Duration `json:"negative_ttl"`
+	// CircuitBreaker configures the circuit breaker used with remote backends.
+	CircuitBreaker CircuitBreakerConfig `json:"circuit_breaker"`
+	// BatchConcurrency bounds the concurrent requests of batch operations on backends
+	// without native support for them (NATS, memcache writes). Defaults to 16.
+	BatchConcurrency int `json:"batch_concurrency"`
 }
 
+// Cache is the cache.CacheInterface returned by NewCache. It wraps the backend,
+// reports misses from every backend as ErrNotFound and supports negative caching.
+type Cache struct {
+	cache.CacheInterface
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+// NewCache creates a cache of the given type. The cache.CacheInterface returned is always a *Cache
+// wrapping the backend, so type assertions on the backend, e.g. to *Nats or *CircuitBreaker, must be
+// made on the value returned by its Backend method.
 func NewCache(logger *zerolog.Logger, cacheType CacheType, config CacheConfig) (cache.CacheInterface, error) {
 	if config.TTL == 0 {
 		return nil, errors.New("TTL configuration value is required")
 	}
+
+	backend, err := newBackend(logger, cacheType, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.CircuitBreaker.Enabled && cacheType.isRemote() {
+		var fallback cache.CacheInterface
+		if config.CircuitBreaker.FallbackSize > 0 {
+			fallback = cache.New(store.NewFreecache(freecache.NewCache(config.CircuitBreaker.FallbackSize),
+				&store.Options{Expiration: config.TTL}))
+		}
+		backend = NewCircuitBreaker(logger, backend, fallback, config.CircuitBreaker)
+	}
+
+	return newCache(backend, &config), nil
+}
+
+func newBackend(logger *zerolog.Logger, cacheType CacheType, config CacheConfig) (cache.CacheInterface, error) {
 	switch cacheType {
 	case FreeCache:
 		{
@@ -56,14 +95,24 @@ func NewCache(logger *zerolog.Logger, cacheType CacheType, config CacheConfig) (
 				&store.Options{Expiration: config.TTL},
 			)
 			cacheManager := cache.New(memcacheStore)
-			return cacheManager, nil
+			return &memcacheBackend{
+				CacheInterface: cacheManager,
+				client:         memCacheClient,
+				concurrency:    config.BatchConcurrency,
+			}, nil
 
 		}
 	case Redis:
 		{
-			redisStore := store.NewRedis(redis.NewClient(&config.RedisConfig), &store.Options{Expiration: config.TTL})
+			redisClient := redis.NewClient(&config.RedisConfig)
+			redisOptions := &store.Options{Expiration: config.TTL}
+			redisStore := store.NewRedis(redisClient, redisOptions)
 			cacheManager := cache.New(redisStore)
-			return cacheManager, nil
+			return &redisBackend{
+				CacheInterface: cacheManager,
+				client:         redisClient,
+				options:        redisOptions,
+			}, nil
 		}
 	case BigCache:
 		{
@@ -78,6 +127,7 @@ func NewCache(logger *zerolog.Logger, cacheType CacheType, config CacheConfig) (
 	case NatsJSCache:
 		{
 			config.NatsJSConfig.KVConfig.TTL = config.TTL
+			config.NatsJSConfig.BatchConcurrency = config.BatchConcurrency
 			cacheManager, err := NewNatsJSCache(logger, config.NatsJSConfig)
 			if err != nil {
 				return nil, err
@@ -91,10 +141,72 @@ func NewCache(logger *zerolog.Logger, cacheType CacheType, config CacheConfig) (
 	}
 }
 
+func newCache(backend cache.CacheInterface, config *CacheConfig) *Cache {
+	negativeTTL := config.NegativeTTL
+	if negativeTTL == 0 {
+		negativeTTL = config.TTL
+	}
+
+	return &Cache{
+		CacheInterface: backend,
+		negativeTTL:    negativeTTL,
+		now:            time.Now,
+	}
+}
+
+// Backend returns the cache wrapped by c: the backend of the configured type, or the circuit
+// breaker in front of it.
+func (c *Cache) Backend() cache.CacheInterface {
+	return c.CacheInterface
+}
+
+// Close releases the resources held by the backend, such as the NATS connection and
+// its log sampling goroutine. The cache must not be used afterwards.
+func (c *Cache) Close() {
+	closeCache(c.CacheInterface)
+}
+
+// closeCache closes c if it has a Close method.
+func closeCache(c cache.CacheInterface) {
+	if closer, ok := c.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
+
+// Get returns the value stored for key. It returns ErrNotFound if there is none,
+// or ErrKnownAbsent if the key was recorded with SetNotFound.
+func (c *Cache) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	return c.result(c.CacheInterface.Get(ctx, key))
+}
+
+// result maps the outcome of a backend read to the one returned by Get.
+func (c *Cache) result(value interface{}, err error) (interface{}, error) {
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if expiresAt, ok := parseNegativeMarker(value); ok {
+		if c.now().Before(expiresAt) {
+			return nil, ErrKnownAbsent
+		}
+		return nil, ErrNotFound
+	}
+
+	return value, nil
+}
+
 func (t CacheType) String() string {
 	return cacheTypeToString[t]
 }
 
+// isRemote reports whether the cache type talks to a server over the network.
+func (t CacheType) isRemote() bool {
+	return t == Redis || t == MemCache || t == NatsJSCache
+}
+
 var cacheTypeToString = map[CacheType]string{
 	FreeCache:   "freecache",
 	BigCache:    "bigcache",
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -41,3 +42,42 @@ func TestFreeCache(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fmt.Sprintf("%s", value), "testValue")
 }
+
+func TestNegativeCacheExpires(t *testing.T) {
+	mycache, err := NewCache(&zerolog.Logger{},
+		FreeCache,
+		CacheConfig{
+			FreeCacheSize: 1000,
+			TTL:           time.Duration(5 * time.Minute),
+			NegativeTTL:   time.Duration(30 * time.Second),
+		})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	mycache.(*Cache).now = func() time.Time { return now }
+
+	err = SetNotFound(context.Background(), mycache, "test")
+	assert.NoError(t, err)
+	_, err = mycache.Get(context.Background(), "test")
+	assert.True(t, errors.Is(err, ErrKnownAbsent))
+
+	now = now.Add(time.Minute)
+	_, err = mycache.Get(context.Background(), "test")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrKnownAbsent))
+}
+
+func TestBackend(t *testing.T) {
+	mycache, err := NewCache(&zerolog.Logger{},
+		Redis,
+		CacheConfig{
+			TTL:            time.Duration(5 * time.Minute),
+			CircuitBreaker: CircuitBreakerConfig{Enabled: true},
+		})
+	assert.NoError(t, err)
+
+	breaker, ok := mycache.(*Cache).Backend().(*CircuitBreaker)
+	assert.True(t, ok)
+	_, ok = breaker.cache.(*redisBackend)
+	assert.True(t, ok)
+}
@@ -0,0 +1,347 @@
+// Package cachetest provides a conformance test suite for cache backends,
+// along with in-process servers that let the suite run against remote
+// backends without any external dependencies.
+package cachetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cache"
+	"github.com/aserto-dev/go-utils/opts"
+	gocache "github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// TTL is the expiration used when the suite checks that entries expire.
+	TTL = time.Second
+
+	concurrency     = 8
+	keysPerRoutine  = 50
+	defaultTestTTL  = 5 * time.Minute
+	invalidationTag = "cachetest"
+)
+
+// Factory creates an empty cache whose entries expire after ttl.
+// It is called once per sub-test.
+type Factory func(t *testing.T, ttl time.Duration) gocache.CacheInterface
+
+type options struct {
+	stringValues bool
+	skipTTL      bool
+	elapse       func(time.Duration)
+}
+
+// WithStringValues declares that the backend accepts string values in addition to []byte.
+func WithStringValues() opts.Param {
+	return func(o interface{}) {
+		if o, ok := o.(*options); ok {
+			o.stringValues = true
+		}
+	}
+}
+
+// WithoutTTL skips the expiration checks, for backends that only evict entries
+// during a background sweep (e.g. bigcache).
+func WithoutTTL() opts.Param {
+	return func(o interface{}) {
+		if o, ok := o.(*options); ok {
+			o.skipTTL = true
+		}
+	}
+}
+
+// WithElapse sets the function used to let time pass in the expiration checks.
+// Backends with a controllable clock (miniredis, the fake memcache server) use it
+// to avoid real sleeps. It defaults to time.Sleep.
+func WithElapse(elapse func(time.Duration)) opts.Param {
+	return func(o interface{}) {
+		if o, ok := o.(*options); ok {
+			o.elapse = elapse
+		}
+	}
+}
+
+// Run checks that the caches created by factory behave like a cache.CacheInterface
+// is expected to: values round-trip through Set and Get, Delete, Invalidate and
+// Clear remove entries, entries expire after their TTL, batch operations work
+// and concurrent use is safe.
+// Misses must be reported as cache.ErrNotFound and the caches must support
+// negative caching, as the ones created by cache.NewCache do.
+func Run(t *testing.T, factory Factory, params ...opts.Param) {
+	o := &options{elapse: time.Sleep}
+	for _, param := range params {
+		param(o)
+	}
+
+	t.Run("GetSet", func(t *testing.T) {
+		testGetSet(t, factory(t, defaultTestTTL))
+	})
+	t.Run("GetMissing", func(t *testing.T) {
+		testGetMissing(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Overwrite", func(t *testing.T) {
+		testOverwrite(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Delete", func(t *testing.T) {
+		testDelete(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Invalidate", func(t *testing.T) {
+		testInvalidate(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Clear", func(t *testing.T) {
+		testClear(t, factory(t, defaultTestTTL))
+	})
+	t.Run("BinaryValues", func(t *testing.T) {
+		testBinaryValues(t, factory(t, defaultTestTTL))
+	})
+	t.Run("StringValues", func(t *testing.T) {
+		if !o.stringValues {
+			t.Skip("backend only stores []byte values")
+		}
+		testStringValues(t, factory(t, defaultTestTTL))
+	})
+	t.Run("TTL", func(t *testing.T) {
+		if o.skipTTL {
+			t.Skip("backend does not expire entries on read")
+		}
+		testTTL(t, factory(t, TTL), o.elapse)
+	})
+	t.Run("NegativeCaching", func(t *testing.T) {
+		testNegativeCaching(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Batch", func(t *testing.T) {
+		testBatch(t, factory(t, defaultTestTTL))
+	})
+	t.Run("Concurrency", func(t *testing.T) {
+		testConcurrency(t, factory(t, defaultTestTTL))
+	})
+}
+
+func testGetSet(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "key", []byte("value"), nil))
+
+	value, err := c.Get(ctx, "key")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), toBytes(t, value))
+}
+
+func testGetMissing(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+
+	value, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(err, cache.ErrNotFound)
+	assert.Nil(value)
+}
+
+func testOverwrite(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "key", []byte("first"), nil))
+	assert.NoError(c.Set(ctx, "key", []byte("second"), nil))
+
+	value, err := c.Get(ctx, "key")
+	assert.NoError(err)
+	assert.Equal([]byte("second"), toBytes(t, value))
+}
+
+func testDelete(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "key", []byte("value"), nil))
+	assert.NoError(c.Delete(ctx, "key"))
+
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(err, cache.ErrNotFound)
+}
+
+func testInvalidate(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "tagged", []byte("value"), &store.Options{
+		Expiration: defaultTestTTL,
+		Tags:       []string{invalidationTag},
+	}))
+
+	_, err := c.Get(ctx, "tagged")
+	assert.NoError(err)
+
+	assert.NoError(c.Invalidate(ctx, store.InvalidateOptions{Tags: []string{invalidationTag}}))
+
+	_, err = c.Get(ctx, "tagged")
+	assert.ErrorIs(err, cache.ErrNotFound)
+}
+
+func testClear(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "key1", []byte("value1"), nil))
+	assert.NoError(c.Set(ctx, "key2", []byte("value2"), nil))
+	assert.NoError(c.Clear(ctx))
+
+	_, err := c.Get(ctx, "key1")
+	assert.ErrorIs(err, cache.ErrNotFound)
+	_, err = c.Get(ctx, "key2")
+	assert.ErrorIs(err, cache.ErrNotFound)
+}
+
+func testBinaryValues(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	binary := []byte{0x00, 0x01, 0xff, '\r', '\n', 0x7f, 0x00}
+	assert.NoError(c.Set(ctx, "binary", binary, nil))
+
+	value, err := c.Get(ctx, "binary")
+	assert.NoError(err)
+	assert.Equal(binary, toBytes(t, value))
+}
+
+func testStringValues(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "string", "value", nil))
+
+	value, err := c.Get(ctx, "string")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), toBytes(t, value))
+}
+
+func testTTL(t *testing.T, c gocache.CacheInterface, elapse func(time.Duration)) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(c.Set(ctx, "key", []byte("value"), nil))
+
+	_, err := c.Get(ctx, "key")
+	assert.NoError(err)
+
+	// Some backends only track expiration with a one second resolution.
+	elapse(2 * TTL)
+
+	_, err = c.Get(ctx, "key")
+	assert.ErrorIs(err, cache.ErrNotFound)
+}
+
+func testNegativeCaching(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(cache.SetNotFound(ctx, c, "absent"))
+
+	_, err := c.Get(ctx, "absent")
+	assert.ErrorIs(err, cache.ErrKnownAbsent)
+	assert.ErrorIs(err, cache.ErrNotFound)
+
+	assert.NoError(c.Set(ctx, "absent", []byte("value"), nil))
+
+	value, err := c.Get(ctx, "absent")
+	assert.NoError(err)
+	assert.Equal([]byte("value"), toBytes(t, value))
+}
+
+func testBatch(t *testing.T, c gocache.CacheInterface) {
+	assert := require.New(t)
+	ctx := context.Background()
+
+	entries := []cache.Entry{
+		{Key: "batch-1", Value: []byte("value-1")},
+		{Key: "batch-2", Value: []byte("value-2")},
+		{Key: "batch-3", Value: []byte("value-3")},
+	}
+	for _, err := range cache.SetMany(ctx, c, entries, nil) {
+		assert.NoError(err)
+	}
+
+	results := cache.GetMany(ctx, c, []string{"batch-1", "missing", "batch-3"})
+	assert.Len(results, 3)
+	assert.NoError(results[0].Err)
+	assert.Equal([]byte("value-1"), toBytes(t, results[0].Value))
+	assert.ErrorIs(results[1].Err, cache.ErrNotFound)
+	assert.NoError(results[2].Err)
+	assert.Equal([]byte("value-3"), toBytes(t, results[2].Value))
+
+	for _, err := range cache.DeleteMany(ctx, c, []string{"batch-1", "batch-2"}) {
+		assert.NoError(err)
+	}
+
+	results = cache.GetMany(ctx, c, []string{"batch-1", "batch-2", "batch-3"})
+	assert.ErrorIs(results[0].Err, cache.ErrNotFound)
+	assert.ErrorIs(results[1].Err, cache.ErrNotFound)
+	assert.NoError(results[2].Err)
+}
+
+func testConcurrency(t *testing.T, c gocache.CacheInterface) {
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrency*keysPerRoutine)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(routine int) {
+			defer wg.Done()
+
+			for j := 0; j < keysPerRoutine; j++ {
+				key := fmt.Sprintf("key-%d-%d", routine, j)
+				expected := []byte(fmt.Sprintf("value-%d-%d", routine, j))
+
+				if err := c.Set(ctx, key, expected, nil); err != nil {
+					errs <- fmt.Errorf("set %s: %w", key, err)
+					continue
+				}
+
+				value, err := c.Get(ctx, key)
+				if err != nil {
+					errs <- fmt.Errorf("get %s: %w", key, err)
+					continue
+				}
+
+				if actual, ok := bytesOf(value); !ok || string(actual) != string(expected) {
+					errs <- fmt.Errorf("get %s: expected %q, got %v", key, expected, value)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// toBytes normalizes the values returned by the different backends, which
+// return either []byte or string regardless of the type that was stored.
+func toBytes(t *testing.T, value interface{}) []byte {
+	b, ok := bytesOf(value)
+	if !ok {
+		t.Fatalf("unexpected value type %T", value)
+	}
+	return b
+}
+
+func bytesOf(value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	default:
+		return nil, false
+	}
+}
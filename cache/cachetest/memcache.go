@@ -0,0 +1,311 @@
+package cachetest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// relativeExpirationLimit is the largest expiration memcached treats as a
+// number of seconds; anything above it is a unix timestamp.
+const relativeExpirationLimit = 60 * 60 * 24 * 30
+
+// MemcacheServer is an in-memory server implementing the subset of the memcached
+// text protocol used by github.com/bradfitz/gomemcache.
+type MemcacheServer struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	items  map[string]*memcacheItem
+	casID  uint64
+	offset time.Duration
+}
+
+type memcacheItem struct {
+	value     []byte
+	flags     uint32
+	casID     uint64
+	expiresAt time.Time
+}
+
+// RunMemcacheServer starts a fake memcache server listening on a random local port.
+// The server is closed when the test finishes.
+func RunMemcacheServer(t *testing.T) *MemcacheServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start memcache server: %v", err)
+	}
+
+	srv := &MemcacheServer{
+		listener: listener,
+		items:    map[string]*memcacheItem{},
+	}
+
+	go srv.serve()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Addr returns the address the server is listening on.
+func (s *MemcacheServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops accepting new connections.
+func (s *MemcacheServer) Close() {
+	_ = s.listener.Close()
+}
+
+// FastForward moves the server clock forward, expiring entries as if d had passed.
+func (s *MemcacheServer) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+func (s *MemcacheServer) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+func (s *MemcacheServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *MemcacheServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := s.dispatch(rw, fields); err != nil {
+			return
+		}
+
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *MemcacheServer) dispatch(rw *bufio.ReadWriter, fields []string) error {
+	switch fields[0] {
+	case "get", "gets":
+		return s.get(rw.Writer, fields[1:])
+	case "set", "add", "replace", "cas":
+		return s.store(rw, fields)
+	case "delete":
+		return s.delete(rw.Writer, fields[1:])
+	case "touch":
+		return s.touch(rw.Writer, fields[1:])
+	case "flush_all":
+		s.mu.Lock()
+		s.items = map[string]*memcacheItem{}
+		s.mu.Unlock()
+		_, err := rw.WriteString("OK\r\n")
+		return err
+	case "version":
+		_, err := rw.WriteString("VERSION cachetest\r\n")
+		return err
+	default:
+		_, err := rw.WriteString("ERROR\r\n")
+		return err
+	}
+}
+
+func (s *MemcacheServer) get(w *bufio.Writer, keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		item := s.lookup(key)
+		if item == nil {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, item.flags, len(item.value), item.casID); err != nil {
+			return err
+		}
+		if _, err := w.Write(item.value); err != nil {
+			return err
+		}
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.WriteString("END\r\n")
+	return err
+}
+
+// store handles "<verb> <key> <flags> <exptime> <bytes> [<cas unique>]" followed by a data block.
+func (s *MemcacheServer) store(rw *bufio.ReadWriter, fields []string) error {
+	verb := fields[0]
+	expectedFields := 5
+	if verb == "cas" {
+		expectedFields = 6
+	}
+	if len(fields) < expectedFields {
+		_, err := rw.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return err
+	}
+
+	flags, err1 := strconv.ParseUint(fields[2], 10, 32)
+	expiration, err2 := strconv.ParseInt(fields[3], 10, 64)
+	size, err3 := strconv.Atoi(fields[4])
+	if err1 != nil || err2 != nil || err3 != nil || size < 0 {
+		_, err := rw.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return err
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(rw, data); err != nil {
+		return err
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		_, err := rw.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fields[1]
+	existing := s.lookup(key)
+
+	switch verb {
+	case "add":
+		if existing != nil {
+			_, err := rw.WriteString("NOT_STORED\r\n")
+			return err
+		}
+	case "replace":
+		if existing == nil {
+			_, err := rw.WriteString("NOT_STORED\r\n")
+			return err
+		}
+	case "cas":
+		casID, err := strconv.ParseUint(fields[5], 10, 64)
+		if err != nil {
+			_, err := rw.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return err
+		}
+		if existing == nil {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		if existing.casID != casID {
+			_, err := rw.WriteString("EXISTS\r\n")
+			return err
+		}
+	}
+
+	s.casID++
+	s.items[key] = &memcacheItem{
+		value:     data[:size],
+		flags:     uint32(flags),
+		casID:     s.casID,
+		expiresAt: s.expiresAt(expiration),
+	}
+
+	_, err := rw.WriteString("STORED\r\n")
+	return err
+}
+
+func (s *MemcacheServer) delete(w *bufio.Writer, args []string) error {
+	if len(args) == 0 {
+		_, err := w.WriteString("ERROR\r\n")
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lookup(args[0]) == nil {
+		_, err := w.WriteString("NOT_FOUND\r\n")
+		return err
+	}
+
+	delete(s.items, args[0])
+	_, err := w.WriteString("DELETED\r\n")
+	return err
+}
+
+func (s *MemcacheServer) touch(w *bufio.Writer, args []string) error {
+	if len(args) < 2 {
+		_, err := w.WriteString("ERROR\r\n")
+		return err
+	}
+
+	expiration, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		_, err := w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.lookup(args[0])
+	if item == nil {
+		_, err := w.WriteString("NOT_FOUND\r\n")
+		return err
+	}
+
+	item.expiresAt = s.expiresAt(expiration)
+	_, err = w.WriteString("TOUCHED\r\n")
+	return err
+}
+
+// lookup returns the item stored under key, dropping it if it has expired.
+// The caller must hold s.mu.
+func (s *MemcacheServer) lookup(key string) *memcacheItem {
+	item, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+
+	if !item.expiresAt.IsZero() && !s.now().Before(item.expiresAt) {
+		delete(s.items, key)
+		return nil
+	}
+
+	return item
+}
+
+// expiresAt converts a memcached expiration into a point in time. The caller must hold s.mu.
+func (s *MemcacheServer) expiresAt(expiration int64) time.Time {
+	switch {
+	case expiration == 0:
+		return time.Time{}
+	case expiration > relativeExpirationLimit:
+		return time.Unix(expiration, 0)
+	default:
+		return s.now().Add(time.Duration(expiration) * time.Second)
+	}
+}
@@ -0,0 +1,47 @@
+package cachetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+const natsStartupTimeout = 10 * time.Second
+
+// RunNatsServer starts an embedded NATS server with JetStream enabled and
+// returns its client URL. The server is shut down when the test finishes.
+func RunNatsServer(t *testing.T) string {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create NATS server: %v", err)
+	}
+
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+
+	if !srv.ReadyForConnections(natsStartupTimeout) {
+		t.Fatal("NATS server did not start in time")
+	}
+
+	return srv.ClientURL()
+}
+
+// RunRedisServer starts an in-process Redis server. Use its FastForward method
+// with WithElapse to check expiration without sleeping.
+// The server is shut down when the test finishes.
+func RunRedisServer(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	return miniredis.RunT(t)
+}
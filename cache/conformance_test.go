@@ -0,0 +1,103 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache"
+	"github.com/aserto-dev/go-utils/cache"
+	"github.com/aserto-dev/go-utils/cache/cachetest"
+	gocache "github.com/eko/gocache/v2/cache"
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func newCache(t *testing.T, cacheType cache.CacheType, config cache.CacheConfig) gocache.CacheInterface {
+	c, err := cache.NewCache(&zerolog.Logger{}, cacheType, config)
+	require.NoError(t, err)
+	return c
+}
+
+func TestFreeCacheConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		return newCache(t, cache.FreeCache, cache.CacheConfig{
+			FreeCacheSize: 1024 * 1024,
+			TTL:           ttl,
+		})
+	})
+}
+
+func TestBigCacheConformance(t *testing.T) {
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		return newCache(t, cache.BigCache, cache.CacheConfig{
+			BigCacheConfig: bigcache.DefaultConfig(ttl),
+			TTL:            ttl,
+		})
+	}, cachetest.WithStringValues(), cachetest.WithoutTTL())
+}
+
+func TestRedisConformance(t *testing.T) {
+	srv := cachetest.RunRedisServer(t)
+
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		srv.FlushAll()
+		return newCache(t, cache.Redis, cache.CacheConfig{
+			RedisConfig: redis.Options{Addr: srv.Addr()},
+			TTL:         ttl,
+		})
+	}, cachetest.WithStringValues(), cachetest.WithElapse(srv.FastForward))
+}
+
+func TestRedisCircuitBreakerConformance(t *testing.T) {
+	srv := cachetest.RunRedisServer(t)
+
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		srv.FlushAll()
+		return newCache(t, cache.Redis, cache.CacheConfig{
+			RedisConfig: redis.Options{Addr: srv.Addr()},
+			TTL:         ttl,
+			CircuitBreaker: cache.CircuitBreakerConfig{
+				Enabled:      true,
+				FallbackSize: 1024 * 1024,
+			},
+		})
+	}, cachetest.WithStringValues(), cachetest.WithElapse(srv.FastForward))
+}
+
+func TestMemCacheConformance(t *testing.T) {
+	srv := cachetest.RunMemcacheServer(t)
+
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		c := newCache(t, cache.MemCache, cache.CacheConfig{
+			MemCacheServers: []string{srv.Addr()},
+			TTL:             ttl,
+		})
+		require.NoError(t, c.Clear(context.Background()))
+		return c
+	}, cachetest.WithElapse(srv.FastForward))
+}
+
+func TestNatsJSCacheConformance(t *testing.T) {
+	url := cachetest.RunNatsServer(t)
+	var buckets int32
+
+	cachetest.Run(t, func(t *testing.T, ttl time.Duration) gocache.CacheInterface {
+		c := newCache(t, cache.NatsJSCache, cache.CacheConfig{
+			NatsJSConfig: cache.NatsConfig{
+				Address: url,
+				KVConfig: nats.KeyValueConfig{
+					Bucket:  fmt.Sprintf("cachetest-%d", atomic.AddInt32(&buckets, 1)),
+					Storage: nats.MemoryStorage,
+				},
+			},
+			TTL: ttl,
+		})
+		t.Cleanup(c.(*cache.Cache).Close)
+		return c
+	}, cachetest.WithStringValues())
+}
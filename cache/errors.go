@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/allegro/bigcache"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// ErrNotFound is returned by the caches created with NewCache when a key has no value,
+	// regardless of the backend.
+	ErrNotFound = errors.New("cache: key not found")
+	// ErrKnownAbsent is returned when a key was recorded as absent with SetNotFound.
+	// It wraps ErrNotFound, so callers that only care about misses can keep checking for that.
+	ErrKnownAbsent = fmt.Errorf("%w: known absent", ErrNotFound)
+)
+
+// gocache's freecache store replaces freecache.ErrNotFound with an error of its own.
+const freecacheNotFound = "value not found in Freecache store"
+
+// isNotFound reports whether err is the way a backend reports a cache miss.
+func isNotFound(err error) bool {
+	switch {
+	case errors.Is(err, ErrNotFound),
+		errors.Is(err, nats.ErrKeyNotFound),
+		errors.Is(err, memcache.ErrCacheMiss),
+		errors.Is(err, redis.Nil),
+		errors.Is(err, bigcache.ErrEntryNotFound):
+		return true
+	default:
+		return err.Error() == freecacheNotFound
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+)
+
+// memcacheBackend reads batches with a single GetMulti call. Memcache has no
+// multi-key writes, so SetMany and DeleteMany fan out over the gocache store.
+type memcacheBackend struct {
+	cache.CacheInterface
+	client      *memcache.Client
+	concurrency int
+}
+
+func (m *memcacheBackend) GetMany(ctx context.Context, keys []string) []Result {
+	results := make([]Result, len(keys))
+
+	items, err := m.client.GetMulti(keys)
+	for i, key := range keys {
+		item, ok := items[key]
+		switch {
+		case ok:
+			results[i].Value = item.Value
+		case err != nil:
+			results[i].Err = err
+		default:
+			results[i].Err = memcache.ErrCacheMiss
+		}
+	}
+
+	return results
+}
+
+func (m *memcacheBackend) SetMany(ctx context.Context, entries []Entry, options *store.Options) []error {
+	errs := make([]error, len(entries))
+	fanOut(len(entries), m.concurrency, func(i int) {
+		errs[i] = m.Set(ctx, entries[i].Key, entries[i].Value, options)
+	})
+	return errs
+}
+
+func (m *memcacheBackend) DeleteMany(ctx context.Context, keys []string) []error {
+	errs := make([]error, len(keys))
+	fanOut(len(keys), m.concurrency, func(i int) {
+		errs[i] = m.Delete(ctx, keys[i])
+	})
+	return errs
+}
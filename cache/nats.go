@@ -3,17 +3,21 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/aserto-dev/go-utils/logsample"
 	"github.com/eko/gocache/v2/store"
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
 )
 
 type Nats struct {
-	Conn   *nats.Conn
-	KV     nats.KeyValue
-	logger *zerolog.Logger
+	Conn             *nats.Conn
+	KV               nats.KeyValue
+	logger           *zerolog.Logger
+	sampler          *logsample.Sampler
+	batchConcurrency int
 }
 
 type NatsConfig struct {
@@ -22,6 +26,12 @@ type NatsConfig struct {
 	KeyPath  string
 	Address  string
 	KVConfig nats.KeyValueConfig
+	// BatchConcurrency bounds the concurrent requests made by GetMany, SetMany and DeleteMany.
+	BatchConcurrency int
+	// LogSampling samples the errors logged by cache operations, so that a failing
+	// NATS server doesn't flood the logs with an error for every request. The number of
+	// errors suppressed is logged every sampling period, and by Close.
+	LogSampling logsample.Config
 }
 
 func NewNatsJSCache(logger *zerolog.Logger, cfg NatsConfig) (*Nats, error) {
@@ -54,16 +64,22 @@ func NewNatsJSCache(logger *zerolog.Logger, cfg NatsConfig) (*Nats, error) {
 	}
 	logger.Debug().Msg("NATS client connected")
 	return &Nats{
-		Conn:   nc,
-		logger: logger,
-		KV:     kv,
+		Conn:             nc,
+		logger:           logger,
+		sampler:          logsample.New(cfg.LogSampling),
+		KV:               kv,
+		batchConcurrency: cfg.BatchConcurrency,
 	}, nil
 }
 
 func (n *Nats) Get(ctx context.Context, key interface{}) (interface{}, error) {
 	entry, err := n.KV.Get(fmt.Sprintf("%v", key))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		n.logger.Trace().Msgf("NATS GET: %v - not found", key)
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		n.logger.Error().Msgf("NATS GET: %v - error %v", key, err)
+		n.sampler.Error(n.logger, err).Msgf("NATS GET: %v - error", key)
 		return nil, err
 	}
 	return entry.Value(), nil
@@ -87,7 +103,7 @@ func (n *Nats) Set(ctx context.Context, key, object interface{}, options *store.
 		{
 			value, err := json.Marshal(object)
 			if err != nil {
-				n.logger.Error().Msgf("Failed to marshal object: %v", object)
+				n.sampler.Error(n.logger, err).Msgf("Failed to marshal object: %v", object)
 			}
 			revision, err := n.KV.Put(fmt.Sprintf("%v", key), value)
 			n.logger.Trace().Msgf("NATS PUT: %v : %v - revision %v", key, object, revision)
@@ -106,7 +122,7 @@ func (n *Nats) Invalidate(ctx context.Context, options store.InvalidateOptions)
 	for _, key := range keys {
 		delerr := n.KV.Delete(key)
 		if delerr != nil {
-			n.logger.Error().Msgf("NATS Delete: %v - error %v", key, delerr)
+			n.sampler.Error(n.logger, delerr).Msgf("NATS Delete: %v - error", key)
 		}
 	}
 	return nil
@@ -119,11 +135,45 @@ func (n *Nats) Clear(ctx context.Context) error {
 	for _, key := range keys {
 		err = n.KV.Purge(key)
 		if err != nil {
-			n.logger.Error().Msgf("NATS Purge: %v - error %v", key, err)
+			n.sampler.Error(n.logger, err).Msgf("NATS Purge: %v - error", key)
 		}
 	}
 	return n.KV.PurgeDeletes()
 }
+
+// Close logs the errors suppressed by log sampling and closes the NATS connection.
+func (n *Nats) Close() {
+	n.sampler.Close()
+	n.Conn.Close()
+}
+
 func (n *Nats) GetType() string {
 	return "nats-jetstream"
 }
+
+// GetMany reads keys concurrently, with at most BatchConcurrency requests in flight.
+func (n *Nats) GetMany(ctx context.Context, keys []string) []Result {
+	results := make([]Result, len(keys))
+	fanOut(len(keys), n.batchConcurrency, func(i int) {
+		results[i].Value, results[i].Err = n.Get(ctx, keys[i])
+	})
+	return results
+}
+
+// SetMany writes entries concurrently, with at most BatchConcurrency requests in flight.
+func (n *Nats) SetMany(ctx context.Context, entries []Entry, options *store.Options) []error {
+	errs := make([]error, len(entries))
+	fanOut(len(entries), n.batchConcurrency, func(i int) {
+		errs[i] = n.Set(ctx, entries[i].Key, entries[i].Value, options)
+	})
+	return errs
+}
+
+// DeleteMany removes keys concurrently, with at most BatchConcurrency requests in flight.
+func (n *Nats) DeleteMany(ctx context.Context, keys []string) []error {
+	errs := make([]error, len(keys))
+	fanOut(len(keys), n.batchConcurrency, func(i int) {
+		errs[i] = n.Delete(ctx, keys[i])
+	})
+	return errs
+}
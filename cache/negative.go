@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+)
+
+// negativePrefix starts the marker values stored by SetNotFound. The marker ends with the
+// unix time in nanoseconds at which it expires, so backends that can't expire individual
+// entries (NATS, bigcache) still honor the negative TTL.
+var negativePrefix = []byte("\x00aserto-cache:not-found:")
+
+// NegativeCacher is implemented by caches that can remember that a key has no value.
+type NegativeCacher interface {
+	// SetNotFound records that key has no value. Until the negative TTL elapses,
+	// Get returns ErrKnownAbsent for it.
+	SetNotFound(ctx context.Context, key interface{}) error
+}
+
+// SetNotFound records that key has no value in c, if c supports negative caching.
+func SetNotFound(ctx context.Context, c cache.CacheInterface, key interface{}) error {
+	negative, ok := c.(NegativeCacher)
+	if !ok {
+		return errors.New("cache does not support negative caching")
+	}
+	return negative.SetNotFound(ctx, key)
+}
+
+// SetNotFound records that key has no value for the configured negative TTL.
+func (c *Cache) SetNotFound(ctx context.Context, key interface{}) error {
+	expiresAt := c.now().Add(c.negativeTTL)
+	return c.CacheInterface.Set(ctx, key, negativeMarker(expiresAt), &store.Options{Expiration: c.negativeTTL})
+}
+
+func negativeMarker(expiresAt time.Time) []byte {
+	return strconv.AppendInt(append([]byte{}, negativePrefix...), expiresAt.UnixNano(), 10)
+}
+
+// parseNegativeMarker returns the expiration of a marker stored by SetNotFound.
+// Redis returns stored bytes as strings, so both are accepted.
+func parseNegativeMarker(value interface{}) (time.Time, bool) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return time.Time{}, false
+	}
+
+	if !bytes.HasPrefix(b, negativePrefix) {
+		return time.Time{}, false
+	}
+
+	nanos, err := strconv.ParseInt(string(b[len(negativePrefix):]), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, nanos), true
+}
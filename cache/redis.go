@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// redisBackend adds pipelined batch operations to the gocache Redis store.
+type redisBackend struct {
+	cache.CacheInterface
+	client  *redis.Client
+	options *store.Options
+}
+
+func (r *redisBackend) GetMany(ctx context.Context, keys []string) []Result {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+
+	results := make([]Result, len(keys))
+	for i, cmd := range cmds {
+		results[i].Value, results[i].Err = cmd.Result()
+	}
+	return results
+}
+
+// SetMany writes all entries in one pipeline. The gocache store keeps track of tags
+// in separate sets, so entries with tags are written one at a time through it.
+func (r *redisBackend) SetMany(ctx context.Context, entries []Entry, options *store.Options) []error {
+	if options == nil {
+		options = r.options
+	}
+	if len(options.TagsValue()) > 0 {
+		return setEach(ctx, r.CacheInterface, entries, options)
+	}
+
+	cmds := make([]*redis.StatusCmd, len(entries))
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, entry := range entries {
+			cmds[i] = pipe.Set(ctx, entry.Key, entry.Value, options.ExpirationValue())
+		}
+		return nil
+	})
+
+	errs := make([]error, len(entries))
+	for i, cmd := range cmds {
+		errs[i] = cmd.Err()
+	}
+	return errs
+}
+
+func (r *redisBackend) DeleteMany(ctx context.Context, keys []string) []error {
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, _ = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Del(ctx, key)
+		}
+		return nil
+	})
+
+	errs := make([]error, len(keys))
+	for i, cmd := range cmds {
+		errs[i] = cmd.Err()
+	}
+	return errs
+}
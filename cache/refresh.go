@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/eko/gocache/v2/cache"
+	"github.com/eko/gocache/v2/store"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// RefreshAheadType is the type returned by RefreshAhead.GetType.
+	RefreshAheadType = "refresh-ahead"
+
+	defaultRefreshAt      = 0.8
+	defaultRefreshTimeout = 30 * time.Second
+)
+
+// RefreshConfig configures a RefreshAhead cache.
+type RefreshConfig struct {
+	// TTL is how long an entry is fresh after it's loaded.
+	TTL time.Duration `json:"ttl"`
+	// RefreshAt is the fraction of TTL after which a read triggers an asynchronous reload
+	// of the entry. Defaults to 0.8.
+	RefreshAt float64 `json:"refresh_at"`
+	// MaxStale is how long past its TTL an entry may still be served while it's
+	// being reloaded, or when reloading it fails.
+	MaxStale time.Duration `json:"max_stale"`
+	// Jitter randomly shortens or lengthens the TTL of each entry by up to this
+	// fraction of it, so that entries loaded together don't expire together.
+	// It must be at least 0 and less than 1.
+	Jitter float64 `json:"jitter"`
+	// RefreshTimeout bounds loads, which run detached from the context of the callers waiting
+	// for them, so that one caller giving up doesn't fail the others. Defaults to 30 seconds.
+	RefreshTimeout time.Duration `json:"refresh_timeout"`
+}
+
+// Loader loads the value of key from the source of truth.
+type Loader func(ctx context.Context, key string) ([]byte, error)
+
+// RefreshAhead is a read-through cache.CacheInterface that reloads entries before
+// they expire. Values are stored in the wrapped cache together with the time they
+// were loaded, so that every instance sharing a remote cache sees the same age.
+type RefreshAhead struct {
+	cache   cache.CacheInterface
+	loader  Loader
+	cfg     RefreshConfig
+	logger  *zerolog.Logger
+	loads   singleflight.Group
+	now     func() time.Time
+	jitterf func() float64
+}
+
+// refreshEntry is what RefreshAhead stores in the wrapped cache.
+type refreshEntry struct {
+	Value    []byte        `json:"value"`
+	LoadedAt time.Time     `json:"loaded_at"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// NewRefreshAhead wraps c, typically a cache created with NewCache, loading missing
+// and expiring entries with loader.
+func NewRefreshAhead(logger *zerolog.Logger, c cache.CacheInterface, loader Loader, cfg RefreshConfig) (*RefreshAhead, error) {
+	if cfg.TTL <= 0 {
+		return nil, errors.New("TTL configuration value is required")
+	}
+	if cfg.Jitter < 0 || cfg.Jitter >= 1 {
+		return nil, fmt.Errorf("jitter %v must be at least 0 and less than 1", cfg.Jitter)
+	}
+	if cfg.RefreshAt <= 0 || cfg.RefreshAt > 1 {
+		cfg.RefreshAt = defaultRefreshAt
+	}
+	if cfg.RefreshTimeout <= 0 {
+		cfg.RefreshTimeout = defaultRefreshTimeout
+	}
+
+	refreshLogger := logger.With().Str("component", "refresh-ahead").Logger()
+
+	return &RefreshAhead{
+		cache:   c,
+		loader:  loader,
+		cfg:     cfg,
+		logger:  &refreshLogger,
+		now:     time.Now,
+		jitterf: rand.Float64,
+	}, nil
+}
+
+// Get returns the value of key. Fresh entries are returned from the cache. Entries
+// past RefreshAt are returned as well and reloaded in the background, and so are
+// expired entries within MaxStale. Missing entries, and expired ones past MaxStale,
+// are loaded before returning.
+func (r *RefreshAhead) Get(ctx context.Context, key interface{}) (interface{}, error) {
+	k := fmt.Sprintf("%v", key)
+
+	entry, err := r.lookup(ctx, k)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			r.logger.Warn().Err(err).Str("key", k).Msg("failed to read cache entry")
+		}
+		return r.load(ctx, k)
+	}
+
+	age := r.now().Sub(entry.LoadedAt)
+	switch {
+	case age < time.Duration(float64(entry.TTL)*r.cfg.RefreshAt):
+		return entry.Value, nil
+	case age < entry.TTL+r.cfg.MaxStale:
+		r.refresh(k)
+		return entry.Value, nil
+	default:
+		return r.load(ctx, k)
+	}
+}
+
+// Set stores value as if it had just been loaded. value must be a []byte or a string.
+func (r *RefreshAhead) Set(ctx context.Context, key, value interface{}, options *store.Options) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("value type %T not supported by refresh-ahead cache", value)
+	}
+
+	return r.store(ctx, fmt.Sprintf("%v", key), b, options)
+}
+
+func (r *RefreshAhead) Delete(ctx context.Context, key interface{}) error {
+	return r.cache.Delete(ctx, fmt.Sprintf("%v", key))
+}
+
+func (r *RefreshAhead) Invalidate(ctx context.Context, options store.InvalidateOptions) error {
+	return r.cache.Invalidate(ctx, options)
+}
+
+func (r *RefreshAhead) Clear(ctx context.Context) error {
+	return r.cache.Clear(ctx)
+}
+
+func (r *RefreshAhead) GetType() string {
+	return RefreshAheadType
+}
+
+func (r *RefreshAhead) lookup(ctx context.Context, key string) (*refreshEntry, error) {
+	value, err := r.cache.Get(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return nil, fmt.Errorf("unexpected cache value type %T", value)
+	}
+
+	entry := &refreshEntry{}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// load calls the loader once for concurrent callers asking for the same key and caches the result.
+// The loader runs with a context of its own, bounded by RefreshTimeout, and each caller stops
+// waiting for it when its own ctx is done.
+func (r *RefreshAhead) load(ctx context.Context, key string) (interface{}, error) {
+	results := r.loads.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.Background(), r.cfg.RefreshTimeout)
+		defer cancel()
+
+		value, err := r.loader(loadCtx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := r.store(loadCtx, key, value, nil); err != nil {
+			r.logger.Warn().Err(err).Str("key", key).Msg("failed to cache loaded value")
+		}
+
+		return value, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// refresh reloads key in the background. The stale entry stays in place if the loader fails.
+func (r *RefreshAhead) refresh(key string) {
+	go func() {
+		if _, err := r.load(context.Background(), key); err != nil {
+			r.logger.Warn().Err(err).Str("key", key).Msg("failed to refresh cache entry, serving stale value")
+		}
+	}()
+}
+
+func (r *RefreshAhead) store(ctx context.Context, key string, value []byte, options *store.Options) error {
+	ttl := r.jitter(r.cfg.TTL)
+
+	raw, err := json.Marshal(&refreshEntry{
+		Value:    value,
+		LoadedAt: r.now(),
+		TTL:      ttl,
+	})
+	if err != nil {
+		return err
+	}
+
+	storeOptions := &store.Options{Expiration: ttl + r.cfg.MaxStale}
+	if options != nil {
+		storeOptions.Cost = options.Cost
+		storeOptions.Tags = options.Tags
+	}
+
+	return r.cache.Set(ctx, key, raw, storeOptions)
+}
+
+// jitter randomly spreads ttl by up to cfg.Jitter of its value in either direction.
+func (r *RefreshAhead) jitter(ttl time.Duration) time.Duration {
+	if r.cfg.Jitter <= 0 {
+		return ttl
+	}
+
+	spread := float64(ttl) * r.cfg.Jitter
+	return ttl + time.Duration(spread*(2*r.jitterf()-1))
+}
@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type testLoader struct {
+	// release, if set, holds loads until it's closed.
+	release chan struct{}
+
+	mu    sync.Mutex
+	value string
+	err   error
+	calls int
+}
+
+func (l *testLoader) load(ctx context.Context, key string) ([]byte, error) {
+	if l.release != nil {
+		<-l.release
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return []byte(l.value), nil
+}
+
+func (l *testLoader) set(value string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.value = value
+	l.err = err
+}
+
+func (l *testLoader) callCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.calls
+}
+
+func newTestRefreshAhead(t *testing.T, loader *testLoader, now *time.Time) *RefreshAhead {
+	backend, err := NewCache(&zerolog.Logger{}, FreeCache, CacheConfig{
+		FreeCacheSize: 1024 * 1024,
+		TTL:           time.Hour,
+	})
+	require.NoError(t, err)
+
+	r, err := NewRefreshAhead(&zerolog.Logger{}, backend, loader.load, RefreshConfig{
+		TTL:       time.Minute,
+		RefreshAt: 0.5,
+		MaxStale:  time.Minute,
+	})
+	require.NoError(t, err)
+
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRefreshAheadLoadsOnMiss(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	loader := &testLoader{value: "v1"}
+	r := newTestRefreshAhead(t, loader, &now)
+
+	value, err := r.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("v1"), value)
+
+	value, err = r.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("v1"), value)
+	assert.Equal(1, loader.callCount())
+}
+
+func TestRefreshAheadRefreshesInBackground(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	loader := &testLoader{value: "v1"}
+	r := newTestRefreshAhead(t, loader, &now)
+
+	_, err := r.Get(context.Background(), "key")
+	assert.NoError(err)
+
+	loader.set("v2", nil)
+	now = now.Add(40 * time.Second)
+
+	value, err := r.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("v1"), value)
+
+	assert.Eventually(func() bool {
+		value, err := r.Get(context.Background(), "key")
+		return err == nil && string(value.([]byte)) == "v2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRefreshAheadServesStaleOnLoaderFailure(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	loader := &testLoader{value: "v1"}
+	r := newTestRefreshAhead(t, loader, &now)
+
+	_, err := r.Get(context.Background(), "key")
+	assert.NoError(err)
+
+	loader.set("", errors.New("boom"))
+	now = now.Add(90 * time.Second)
+
+	value, err := r.Get(context.Background(), "key")
+	assert.NoError(err)
+	assert.Equal([]byte("v1"), value)
+	assert.Eventually(func() bool { return loader.callCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	now = now.Add(time.Minute)
+
+	_, err = r.Get(context.Background(), "key")
+	assert.Error(err)
+}
+
+func TestRefreshAheadJitter(t *testing.T) {
+	assert := require.New(t)
+	r := &RefreshAhead{cfg: RefreshConfig{Jitter: 0.1}}
+
+	r.jitterf = func() float64 { return 0 }
+	assert.Equal(90*time.Second, r.jitter(100*time.Second))
+
+	r.jitterf = func() float64 { return 1 }
+	assert.Equal(110*time.Second, r.jitter(100*time.Second))
+
+	r.jitterf = func() float64 { return 0.5 }
+	assert.Equal(100*time.Second, r.jitter(100*time.Second))
+}
+
+func TestRefreshAheadInvalidJitter(t *testing.T) {
+	assert := require.New(t)
+	loader := &testLoader{value: "v1"}
+
+	for _, jitter := range []float64{-0.1, 1, 1.5} {
+		_, err := NewRefreshAhead(&zerolog.Logger{}, newFreecache(), loader.load, RefreshConfig{
+			TTL:    time.Minute,
+			Jitter: jitter,
+		})
+		assert.Error(err, "jitter %v", jitter)
+	}
+
+	_, err := NewRefreshAhead(&zerolog.Logger{}, newFreecache(), loader.load, RefreshConfig{
+		TTL:    time.Minute,
+		Jitter: 0.5,
+	})
+	assert.NoError(err)
+}
+
+func TestRefreshAheadCallerCancellation(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+	loader := &testLoader{value: "v1", release: make(chan struct{})}
+	r := newTestRefreshAhead(t, loader, &now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := r.Get(ctx, "key")
+		first <- err
+	}()
+
+	second := make(chan interface{}, 1)
+	go func() {
+		// Joins the load started by the first caller, or starts it.
+		value, err := r.Get(context.Background(), "key")
+		if err != nil {
+			value = err
+		}
+		second <- value
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.ErrorIs(<-first, context.Canceled)
+
+	// The first caller giving up doesn't fail the load the second one waits for.
+	close(loader.release)
+	assert.Equal([]byte("v1"), <-second)
+	assert.Equal(1, loader.callCount())
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/cerr/registry"
+)
+
+const (
+	jsonFile     = "errors.json"
+	markdownFile = "errors.md"
+	openAPIFile  = "openapi.json"
+)
+
+// Entry describes an error of the catalog.
+type Entry struct {
+	Code       string `json:"code"`
+	Domain     string `json:"domain"`
+	Name       string `json:"name"`
+	GRPCCode   string `json:"grpc_code"`
+	HTTPStatus int    `json:"http_status"`
+	Message    string `json:"message"`
+	Comment    string `json:"comment"`
+}
+
+// declaration is what the cerr sources tell about an error.
+type declaration struct {
+	name    string
+	comment string
+}
+
+var codePattern = regexp.MustCompile(`^E\d{5}$`)
+
+// buildCatalog returns the errors of the cerr registry, sorted by code, with the names
+// and comments of their declarations in the sources found in src and its subdirectories.
+func buildCatalog(src string) ([]Entry, error) {
+	declarations, err := parseDeclarations(src)
+	if err != nil {
+		return nil, err
+	}
+
+	all := cerr.All()
+	catalog := make([]Entry, 0, len(all))
+	for _, asertoErr := range all {
+		decl, ok := declarations[asertoErr.Code]
+		if !ok {
+			return nil, fmt.Errorf("no declaration found for error %s in %s", asertoErr.Code, src)
+		}
+
+		domain, _ := registry.Domain(asertoErr.Code)
+		catalog = append(catalog, Entry{
+			Code:       asertoErr.Code,
+			Domain:     domain,
+			Name:       decl.name,
+			GRPCCode:   asertoErr.StatusCode.String(),
+			HTTPStatus: asertoErr.HTTPCode,
+			Message:    asertoErr.Message,
+			Comment:    decl.comment,
+		})
+	}
+
+	return catalog, nil
+}
+
+// parseDeclarations finds the variables initialized with an error code, such as
+// newErr("E10000", ...) in cerr or domain.New("E10001", ...) in its domain subpackages,
+// in the packages in src and its subdirectories, and returns their names and doc comments
+// by error code.
+func parseDeclarations(src string) (map[string]declaration, error) {
+	declarations := map[string]declaration{}
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != src && (d.Name() == "cmd" || d.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		return parseDir(path, declarations)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return declarations, nil
+}
+
+// parseDir adds the declarations of the package in dir to declarations.
+func parseDir(dir string, declarations map[string]declaration) error {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				spec, ok := n.(*ast.ValueSpec)
+				if !ok || len(spec.Names) != 1 || len(spec.Values) != 1 {
+					return true
+				}
+
+				code, ok := declaredCode(spec.Values[0])
+				if !ok {
+					return true
+				}
+
+				declarations[code] = declaration{
+					name:    spec.Names[0].Name,
+					comment: strings.Join(strings.Fields(spec.Doc.Text()), " "),
+				}
+				return false
+			})
+		}
+	}
+
+	return nil
+}
+
+// declaredCode returns the error code passed as first argument if expr is a call.
+func declaredCode(expr ast.Expr) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+
+	code, err := strconv.Unquote(lit.Value)
+	if err != nil || !codePattern.MatchString(code) {
+		return "", false
+	}
+	return code, true
+}
+
+// render returns the content of the catalog files by file name.
+func render(catalog []Entry) (map[string][]byte, error) {
+	jsonCatalog, err := marshal(catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	openAPI, err := marshal(openAPIComponents(catalog))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		jsonFile:     jsonCatalog,
+		markdownFile: markdown(catalog),
+		openAPIFile:  openAPI,
+	}, nil
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func markdown(catalog []Entry) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("<!-- Code generated by errcatalog. DO NOT EDIT. -->\n\n")
+	buf.WriteString("# Error codes\n\n")
+	buf.WriteString("| Code | Domain | Name | gRPC code | HTTP status | Message | Description |\n")
+	buf.WriteString("|------|--------|------|-----------|-------------|---------|-------------|\n")
+
+	for _, e := range catalog {
+		fmt.Fprintf(buf, "| %s | %s | %s | %s | %d | %s | %s |\n",
+			e.Code, e.Domain, e.Name, e.GRPCCode, e.HTTPStatus, escapeCell(e.Message), escapeCell(e.Comment))
+	}
+
+	return buf.Bytes()
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// openAPIComponents returns an OpenAPI components section with a schema enumerating the error
+// codes, a schema of the error body and an example of every error.
+func openAPIComponents(catalog []Entry) interface{} {
+	codes := make([]string, len(catalog))
+	names := make([]string, len(catalog))
+	descriptions := make([]string, len(catalog))
+	examples := map[string]interface{}{}
+
+	for i, e := range catalog {
+		codes[i] = e.Code
+		names[i] = e.Name
+		descriptions[i] = e.Message
+		examples[e.Code] = map[string]interface{}{
+			"summary":     e.Message,
+			"description": e.Comment,
+			"value": map[string]interface{}{
+				"code":    e.Code,
+				"message": e.Message,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"ErrorCode": map[string]interface{}{
+					"type":                "string",
+					"description":         "Code of an error returned by Aserto services.",
+					"enum":                codes,
+					"x-enum-varnames":     names,
+					"x-enum-descriptions": descriptions,
+				},
+				"Error": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"code", "message"},
+					"properties": map[string]interface{}{
+						"code":    map[string]interface{}{"$ref": "#/components/schemas/ErrorCode"},
+						"message": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+			"examples": examples,
+		},
+	}
+}
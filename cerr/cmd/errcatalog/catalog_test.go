@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cerrDir    = "../.."
+	catalogDir = "../../catalog"
+)
+
+func TestCatalogEntries(t *testing.T) {
+	assert := require.New(t)
+
+	catalog, err := buildCatalog(cerrDir)
+	assert.NoError(err)
+	assert.Len(catalog, len(cerr.All()))
+
+	assert.Equal(Entry{
+		Code:       "E10012",
+		Domain:     "tenant",
+		Name:       "ErrAccountNotFound",
+		GRPCCode:   "NotFound",
+		HTTPStatus: 404,
+		Message:    "account not found",
+		Comment:    "Returned if an account id is not found in the database",
+	}, catalog[12])
+}
+
+// TestCatalogUpToDate fails when the errors change without regenerating the catalog.
+func TestCatalogUpToDate(t *testing.T) {
+	assert := require.New(t)
+
+	catalog, err := buildCatalog(cerrDir)
+	assert.NoError(err)
+
+	files, err := render(catalog)
+	assert.NoError(err)
+
+	for name, content := range files {
+		existing, err := os.ReadFile(filepath.Join(catalogDir, name))
+		assert.NoError(err)
+		assert.Equal(string(content), string(existing), "%s is out of date, run go generate ./cerr", name)
+	}
+}
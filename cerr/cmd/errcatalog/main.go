@@ -0,0 +1,49 @@
+// Command errcatalog generates the catalog of the errors declared in package cerr,
+// as JSON, Markdown and an OpenAPI components section.
+//
+// It's run by go generate in package cerr:
+//
+//	go generate ./cerr
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	src := flag.String("src", ".", "directory of the cerr sources, read for the comments of the errors")
+	out := flag.String("out", "catalog", "directory the catalog files are written to")
+	flag.Parse()
+
+	if err := run(*src, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "errcatalog: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(src, out string) error {
+	catalog, err := buildCatalog(src)
+	if err != nil {
+		return err
+	}
+
+	files, err := render(catalog)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(out, name), content, 0o600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
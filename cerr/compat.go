@@ -0,0 +1,79 @@
+package cerr
+
+import (
+	"github.com/aserto-dev/go-utils/cerr/directory"
+	"github.com/aserto-dev/go-utils/cerr/invite"
+	"github.com/aserto-dev/go-utils/cerr/policy"
+	"github.com/aserto-dev/go-utils/cerr/runtime"
+	"github.com/aserto-dev/go-utils/cerr/scc"
+	"github.com/aserto-dev/go-utils/cerr/tenant"
+)
+
+// The errors below moved to domain subpackages. They are kept here for compatibility, and are
+// the same values, so errors compare equal whichever package they are taken from.
+var (
+	// Moved to package tenant.
+	ErrNoTenantID             = tenant.ErrNoTenantID
+	ErrInvalidTenantID        = tenant.ErrInvalidTenantID
+	ErrInvalidTenantName      = tenant.ErrInvalidTenantName
+	ErrAccountNotFound        = tenant.ErrAccountNotFound
+	ErrInvalidAccountID       = tenant.ErrInvalidAccountID
+	ErrAuth0UserSetup         = tenant.ErrAuth0UserSetup
+	ErrInvalidEmail           = tenant.ErrInvalidEmail
+	ErrInvalidAuth0ID         = tenant.ErrInvalidAuth0ID
+	ErrHasAccount             = tenant.ErrHasAccount
+	ErrLastOwner              = tenant.ErrLastOwner
+	ErrUserNotFound           = tenant.ErrUserNotFound
+	ErrUserAlreadyExists      = tenant.ErrUserAlreadyExists
+	ErrPersonalTenantRequired = tenant.ErrPersonalTenantRequired
+	ErrTenantDeleted          = tenant.ErrTenantDeleted
+	ErrTenantNotFound         = tenant.ErrTenantNotFound
+
+	// Moved to package invite.
+	ErrInviteExists          = invite.ErrInviteExists
+	ErrInviteExpired         = invite.ErrInviteExpired
+	ErrAlreadyMember         = invite.ErrAlreadyMember
+	ErrInviteForAnotherUser  = invite.ErrInviteForAnotherUser
+	ErrInviteAlreadyAccepted = invite.ErrInviteAlreadyAccepted
+	ErrInviteAlreadyDeclined = invite.ErrInviteAlreadyDeclined
+	ErrInviteCanceled        = invite.ErrInviteCanceled
+
+	// Moved to package scc.
+	ErrGithubAccessToken       = scc.ErrGithubAccessToken
+	ErrSCC                     = scc.ErrSCC
+	ErrRepoAlreadyConnected    = scc.ErrRepoAlreadyConnected
+	ErrGithubSecret            = scc.ErrGithubSecret
+	ErrGitlabAccessToken       = scc.ErrGitlabAccessToken
+	ErrSCCOrganizationNotFound = scc.ErrSCCOrganizationNotFound
+	ErrSCCRepoNotFound         = scc.ErrSCCRepoNotFound
+
+	// Moved to package directory.
+	ErrDirectoryObjectTypeUnknown   = directory.ErrDirectoryObjectTypeUnknown
+	ErrDirectoryRelationTypeUnknown = directory.ErrDirectoryRelationTypeUnknown
+	ErrDirectoryPermissionUnknown   = directory.ErrDirectoryPermissionUnknown
+	ErrDirectoryObjectNotFound      = directory.ErrDirectoryObjectNotFound
+	ErrDirectoryRelationNotFound    = directory.ErrDirectoryRelationNotFound
+	ErrDirectoryStoreTenantNotFound = directory.ErrDirectoryStoreTenantNotFound
+
+	// Moved to package policy.
+	ErrPolicyNotFound                   = policy.ErrPolicyNotFound
+	ErrInvalidPolicyID                  = policy.ErrInvalidPolicyID
+	ErrDuplicatePolicyName              = policy.ErrDuplicatePolicyName
+	ErrModuleNotFound                   = policy.ErrModuleNotFound
+	ErrPolicyBuilderNotFound            = policy.ErrPolicyBuilderNotFound
+	ErrInvalidPolicyBuilderID           = policy.ErrInvalidPolicyBuilderID
+	ErrInvalidPolicyTag                 = policy.ErrInvalidPolicyTag
+	ErrPolicyInstanceNotFound           = policy.ErrPolicyInstanceNotFound
+	ErrPolicyRepositoryNotFound         = policy.ErrPolicyRepositoryNotFound
+	ErrPolicySourceNotFound             = policy.ErrPolicySourceNotFound
+	ErrPolicySourceAlreadySet           = policy.ErrPolicySourceAlreadySet
+	ErrPolicyRepositoryAlreadyConnected = policy.ErrPolicyRepositoryAlreadyConnected
+
+	// Moved to package runtime.
+	ErrRuntimeLoading       = runtime.ErrRuntimeLoading
+	ErrBadQuery             = runtime.ErrBadQuery
+	ErrQueryExecutionFailed = runtime.ErrQueryExecutionFailed
+	ErrInvalidDecision      = runtime.ErrInvalidDecision
+	ErrBadRuntime           = runtime.ErrBadRuntime
+	ErrDiscoveryFailed      = runtime.ErrDiscoveryFailed
+)
@@ -0,0 +1,35 @@
+// Package directory declares the errors about the directory,
+// with codes E14000 to E14999.
+package directory
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E14000 to E14999.
+var domain = registry.Reserve("directory", 14000, 14999,
+	"E10063",
+	"E10064",
+	"E10065",
+	"E10066",
+	"E10067",
+	"E10069",
+)
+
+var (
+	// Returned if object type is not defined in the directory
+	ErrDirectoryObjectTypeUnknown = domain.New("E10063", codes.Unknown, http.StatusNotFound, "directory object type unknown")
+	// Returned if relation type is not defined in the directory
+	ErrDirectoryRelationTypeUnknown = domain.New("E10064", codes.NotFound, http.StatusNotFound, "directory relation type unknown")
+	// Returned if permission is not defined in the directory
+	ErrDirectoryPermissionUnknown = domain.New("E10065", codes.NotFound, http.StatusNotFound, "directory permission unknown")
+	// Returned if object object id is not found in the directory
+	ErrDirectoryObjectNotFound = domain.New("E10066", codes.NotFound, http.StatusNotFound, "directory object not found")
+	// Returned if relation object is not found in the directory
+	ErrDirectoryRelationNotFound = domain.New("E10067", codes.NotFound, http.StatusNotFound, "directory relation not found")
+	// Returned when tenant store for given tenant id is not found in directory.
+	ErrDirectoryStoreTenantNotFound = domain.New("E10069", codes.NotFound, http.StatusNotFound, "tenant store not found")
+)
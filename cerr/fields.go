@@ -0,0 +1,89 @@
+package cerr
+
+import (
+	"errors"
+	"strconv"
+
+	aerr "github.com/aserto-dev/errors"
+)
+
+// Keys of the fields set by the typed field helpers below. They are the keys found in the
+// error's Data(), in the metadata of its gRPC status, and in the templates of localized messages.
+const (
+	TenantKey       = "tenant_id"
+	AccountKey      = "account_id"
+	PolicyIDKey     = "policy_id"
+	ConnectionIDKey = "connection_id"
+	AttemptKey      = "attempt"
+)
+
+// WithTenant returns a copy of err with the ID of the tenant it happened in.
+func WithTenant(err *aerr.AsertoError, tenantID string) *aerr.AsertoError {
+	return err.Str(TenantKey, tenantID)
+}
+
+// WithAccount returns a copy of err with the ID of the account it concerns.
+func WithAccount(err *aerr.AsertoError, accountID string) *aerr.AsertoError {
+	return err.Str(AccountKey, accountID)
+}
+
+// WithPolicyID returns a copy of err with the ID of the policy it concerns.
+func WithPolicyID(err *aerr.AsertoError, policyID string) *aerr.AsertoError {
+	return err.Str(PolicyIDKey, policyID)
+}
+
+// WithConnectionID returns a copy of err with the ID of the connection it concerns.
+func WithConnectionID(err *aerr.AsertoError, connectionID string) *aerr.AsertoError {
+	return err.Str(ConnectionIDKey, connectionID)
+}
+
+// WithAttempt returns a copy of err with the number of the attempt that failed, starting at 1.
+func WithAttempt(err *aerr.AsertoError, attempt int) *aerr.AsertoError {
+	return err.Int(AttemptKey, attempt)
+}
+
+// Tenant returns the tenant ID set with WithTenant on an error of the chain of err.
+func Tenant(err error) (string, bool) {
+	return field(err, TenantKey)
+}
+
+// Account returns the account ID set with WithAccount on an error of the chain of err.
+func Account(err error) (string, bool) {
+	return field(err, AccountKey)
+}
+
+// PolicyID returns the policy ID set with WithPolicyID on an error of the chain of err.
+func PolicyID(err error) (string, bool) {
+	return field(err, PolicyIDKey)
+}
+
+// ConnectionID returns the connection ID set with WithConnectionID on an error of the chain of err.
+func ConnectionID(err error) (string, bool) {
+	return field(err, ConnectionIDKey)
+}
+
+// Attempt returns the attempt number set with WithAttempt on an error of the chain of err.
+func Attempt(err error) (int, bool) {
+	value, ok := field(err, AttemptKey)
+	if !ok {
+		return 0, false
+	}
+
+	attempt, convErr := strconv.Atoi(value)
+	return attempt, convErr == nil
+}
+
+// field returns the value of key in the data of the first *errors.AsertoError of the chain
+// of err that has it.
+func field(err error, key string) (string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		asertoErr, ok := err.(*aerr.AsertoError)
+		if !ok {
+			continue
+		}
+		if value, ok := asertoErr.Data()[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,54 @@
+package cerr_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields(t *testing.T) {
+	assert := require.New(t)
+
+	err := cerr.WithAttempt(cerr.WithPolicyID(cerr.WithTenant(cerr.ErrPolicyNotFound, "tenant-1"), "policy-1"), 3)
+
+	tenant, ok := cerr.Tenant(err)
+	assert.True(ok)
+	assert.Equal("tenant-1", tenant)
+
+	policyID, ok := cerr.PolicyID(err)
+	assert.True(ok)
+	assert.Equal("policy-1", policyID)
+
+	attempt, ok := cerr.Attempt(err)
+	assert.True(ok)
+	assert.Equal(3, attempt)
+
+	_, ok = cerr.Account(err)
+	assert.False(ok)
+
+	// The canonical error is left untouched.
+	_, ok = cerr.Tenant(cerr.ErrPolicyNotFound)
+	assert.False(ok)
+
+	assert.Equal("tenant-1", err.Data()[cerr.TenantKey])
+}
+
+func TestFieldsInChain(t *testing.T) {
+	assert := require.New(t)
+
+	inner := cerr.WithConnectionID(cerr.ErrConnectionNotFound, "conn-1")
+	err := fmt.Errorf("loading: %w", cerr.WithAccount(cerr.ErrUnknown, "account-1").Err(inner))
+
+	connectionID, ok := cerr.ConnectionID(err)
+	assert.True(ok)
+	assert.Equal("conn-1", connectionID)
+
+	account, ok := cerr.Account(err)
+	assert.True(ok)
+	assert.Equal("account-1", account)
+
+	_, ok = cerr.Attempt(fmt.Errorf("plain"))
+	assert.False(ok)
+}
@@ -0,0 +1,3 @@
+package cerr
+
+//go:generate go run ./cmd/errcatalog -src . -out catalog
@@ -0,0 +1,138 @@
+package grpcerr
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// codePattern matches the code at the start of the message of errors formatted by AsertoError.Error.
+var codePattern = regexp.MustCompile(`^(E\d{5})\b`)
+
+// RemoteError is an error received from another service, decoded into the cerr error of its code.
+// errors.Is matches it with the cerr error of the same code, e.g. cerr.ErrPolicyNotFound, and
+// errors.As finds the *errors.AsertoError carrying the fields received.
+type RemoteError struct {
+	// Err is a copy of the cerr error of the received code, with the gRPC code and fields received.
+	Err *aerr.AsertoError
+	// Status is the status received.
+	Status *status.Status
+}
+
+func (e *RemoteError) Error() string {
+	return e.Err.Error()
+}
+
+// Is reports whether target is an *errors.AsertoError with the same code.
+func (e *RemoteError) Is(target error) bool {
+	t, ok := target.(*aerr.AsertoError)
+	return ok && t.Code == e.Err.Code
+}
+
+// Unwrap returns the decoded error.
+func (e *RemoteError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status received, so that the error can be passed on as is.
+func (e *RemoteError) GRPCStatus() *status.Status {
+	return e.Status
+}
+
+// FromStatus decodes the status carried by err into a *RemoteError.
+// The code is read from the google.rpc.ErrorInfo details, falling back on a code at the
+// start of the status message. err is returned unchanged if it has no status, or if its code
+// isn't in the cerr registry.
+func FromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	code, metadata := decode(s)
+	canonical, ok := cerr.Lookup(code)
+	if !ok {
+		return err
+	}
+
+	decoded := canonical.Copy()
+	for k, v := range metadata {
+		decoded = decoded.Str(k, v)
+	}
+	decoded.StatusCode = s.Code()
+	decoded.HTTPCode = canonical.HTTPCode
+
+	return &RemoteError{Err: decoded, Status: s}
+}
+
+// decode returns the error code found in s and the metadata of its ErrorInfo detail.
+func decode(s *status.Status) (string, map[string]string) {
+	for _, detail := range s.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		if _, ok := cerr.Lookup(info.Reason); ok {
+			return info.Reason, info.Metadata
+		}
+		if _, ok := cerr.Lookup(info.Domain); ok {
+			return info.Domain, info.Metadata
+		}
+	}
+
+	if match := codePattern.FindStringSubmatch(s.Message()); match != nil {
+		return match[1], nil
+	}
+
+	return "", nil
+}
+
+// UnaryClientInterceptor decodes the errors of unary calls with FromStatus.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor decodes the errors of streams with FromStatus.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, FromStatus(err)
+		}
+		return &clientStream{ClientStream: stream}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	return decodeStreamErr(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return decodeStreamErr(s.ClientStream.RecvMsg(m))
+}
+
+// decodeStreamErr decodes stream errors, leaving io.EOF alone as callers compare it directly.
+func decodeStreamErr(err error) error {
+	if err == io.EOF {
+		return err
+	}
+	return FromStatus(err)
+}
@@ -0,0 +1,90 @@
+package grpcerr_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/cerr/grpcerr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func withClientInterceptors() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(grpcerr.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(grpcerr.StreamClientInterceptor()),
+	}
+}
+
+func TestUnaryRoundTrip(t *testing.T) {
+	assert := require.New(t)
+
+	client, _ := newClient(t, cerr.ErrPolicyNotFound.Str("policy_id", "p1").Msg("no such policy"), withClientInterceptors()...)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.ErrorIs(err, cerr.ErrPolicyNotFound)
+	assert.False(errors.Is(err, cerr.ErrAccountNotFound))
+	assert.Equal(codes.NotFound, status.Code(err))
+
+	var asertoErr *aerr.AsertoError
+	assert.ErrorAs(err, &asertoErr)
+	assert.Equal("p1", asertoErr.Data()["policy_id"])
+	assert.Equal("no such policy", asertoErr.Data()[cerr.MessageKey])
+	assert.Equal(cerr.ErrPolicyNotFound.HTTPCode, asertoErr.HTTPCode)
+
+	// The canonical error isn't modified by decoding.
+	assert.Empty(cerr.ErrPolicyNotFound.Data())
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	assert := require.New(t)
+
+	client, _ := newClient(t, cerr.ErrTenantNotFound, withClientInterceptors()...)
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(err)
+
+	_, err = stream.Recv()
+	assert.ErrorIs(err, cerr.ErrTenantNotFound)
+}
+
+func TestFromStatusMessageCode(t *testing.T) {
+	assert := require.New(t)
+
+	err := grpcerr.FromStatus(status.Error(codes.Unavailable, cerr.ErrConnection.Msg("dial tcp").Error()))
+
+	assert.ErrorIs(err, cerr.ErrConnection)
+	assert.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestFromStatusLegacyDomain(t *testing.T) {
+	assert := require.New(t)
+
+	// AsertoError.GRPCStatus only sets the code as the ErrorInfo domain.
+	err := grpcerr.FromStatus(cerr.ErrInvalidAccountID.Str("account_id", "x").GRPCStatus().Err())
+
+	assert.ErrorIs(err, cerr.ErrInvalidAccountID)
+
+	var asertoErr *aerr.AsertoError
+	assert.ErrorAs(err, &asertoErr)
+	assert.Equal("x", asertoErr.Data()["account_id"])
+}
+
+func TestFromStatusUnchanged(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Nil(grpcerr.FromStatus(nil))
+
+	plain := errors.New("boom")
+	assert.Same(plain, grpcerr.FromStatus(plain))
+
+	unknown := status.Error(codes.NotFound, "E99999 not a known code")
+	assert.Equal(unknown, grpcerr.FromStatus(unknown))
+}
@@ -0,0 +1,109 @@
+// Package grpcerr converts errors declared in package cerr to and from gRPC statuses.
+package grpcerr
+
+import (
+	"context"
+	"errors"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor converts the errors returned by unary handlers to statuses with ToStatus,
+// and logs them with logger.
+func UnaryServerInterceptor(logger *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, convert(logger, info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor converts the errors returned by stream handlers to statuses with ToStatus,
+// and logs them with logger.
+func StreamServerInterceptor(logger *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return convert(logger, info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+// ToStatus returns the gRPC status of err.
+//
+// An *errors.AsertoError found in the chain of err gives its gRPC code and message, and a
+// google.rpc.ErrorInfo detail whose Reason and Domain are the error code (e.g. "E10012") and whose
+// Metadata are the error's fields. The errors it wraps aren't part of the status.
+// Errors that already carry a status keep it, and context errors get the matching status.
+// Any other error becomes cerr.ErrUnknown, so that internal messages don't leak to clients.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	var asertoErr *aerr.AsertoError
+	if errors.As(err, &asertoErr) {
+		return asertoStatus(asertoErr)
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return grpcErr.GRPCStatus()
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+
+	return asertoStatus(cerr.ErrUnknown)
+}
+
+func asertoStatus(asertoErr *aerr.AsertoError) *status.Status {
+	s, err := status.New(asertoErr.StatusCode, asertoErr.Message).WithDetails(&errdetails.ErrorInfo{
+		Reason:   asertoErr.Code,
+		Domain:   asertoErr.Code,
+		Metadata: asertoErr.Data(),
+	})
+	if err != nil {
+		return status.New(codes.Internal, cerr.ErrUnknown.Message)
+	}
+	return s
+}
+
+// convert returns the status error of err, logging err with all its details.
+func convert(logger *zerolog.Logger, method string, err error) error {
+	s := ToStatus(err)
+
+	event := logger.Warn()
+	if isServerError(s.Code()) {
+		event = logger.Error()
+	}
+
+	event = event.Str("method", method).Str("grpc_code", s.Code().String())
+
+	var asertoErr *aerr.AsertoError
+	if errors.As(err, &asertoErr) {
+		event = event.Str("code", asertoErr.Code).Fields(asertoErr.Fields())
+	}
+
+	event.Err(err).Msg("request failed")
+
+	return s.Err()
+}
+
+// isServerError reports whether code is caused by the server rather than by the request.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented, codes.Unavailable:
+		return true
+	}
+	return false
+}
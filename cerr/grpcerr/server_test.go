@@ -0,0 +1,157 @@
+package grpcerr_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/cerr/grpcerr"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthServer fails every call with err.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+func (s *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	return s.err
+}
+
+// newClient starts a server whose handlers fail with err, and returns a client of it and the server's log.
+func newClient(t *testing.T, err error, clientOpts ...grpc.DialOption) (grpc_health_v1.HealthClient, *bytes.Buffer) {
+	t.Helper()
+
+	log := &bytes.Buffer{}
+	logger := zerolog.New(log)
+
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcerr.UnaryServerInterceptor(&logger)),
+		grpc.StreamInterceptor(grpcerr.StreamServerInterceptor(&logger)),
+	)
+	grpc_health_v1.RegisterHealthServer(s, &healthServer{err: err})
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, clientOpts...)
+
+	conn, dialErr := grpc.Dial("bufnet", dialOpts...)
+	require.NoError(t, dialErr)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn), log
+}
+
+func errorInfo(t *testing.T, s *status.Status) *errdetails.ErrorInfo {
+	t.Helper()
+
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	require.Fail(t, "no ErrorInfo detail in status")
+	return nil
+}
+
+func TestUnaryAsertoError(t *testing.T) {
+	assert := require.New(t)
+
+	client, log := newClient(t, fmt.Errorf("wrapped: %w",
+		cerr.ErrAccountNotFound.Err(errors.New("select failed")).Str("account_id", "42")))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	s := status.Convert(err)
+	assert.Equal(codes.NotFound, s.Code())
+	assert.Equal("account not found", s.Message())
+
+	info := errorInfo(t, s)
+	assert.Equal("E10012", info.Reason)
+	assert.Equal("E10012", info.Domain)
+	assert.Equal(map[string]string{"account_id": "42"}, info.Metadata)
+
+	assert.NotContains(err.Error(), "select failed")
+	assert.Contains(log.String(), `"level":"warn"`)
+	assert.Contains(log.String(), `"method":"/grpc.health.v1.Health/Check"`)
+	assert.Contains(log.String(), `"code":"E10012"`)
+	assert.Contains(log.String(), "select failed")
+}
+
+func TestUnaryUnknownError(t *testing.T) {
+	assert := require.New(t)
+
+	client, log := newClient(t, errors.New("pq: password authentication failed for user admin"))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	s := status.Convert(err)
+	assert.Equal(codes.Internal, s.Code())
+	assert.Equal(cerr.ErrUnknown.Message, s.Message())
+	assert.Equal(cerr.ErrUnknown.Code, errorInfo(t, s).Reason)
+	assert.NotContains(err.Error(), "password")
+
+	assert.Contains(log.String(), `"level":"error"`)
+	assert.Contains(log.String(), "password authentication failed")
+}
+
+func TestUnaryStatusError(t *testing.T) {
+	assert := require.New(t)
+
+	client, _ := newClient(t, status.Error(codes.PermissionDenied, "go away"))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	s := status.Convert(err)
+	assert.Equal(codes.PermissionDenied, s.Code())
+	assert.Equal("go away", s.Message())
+}
+
+func TestStreamAsertoError(t *testing.T) {
+	assert := require.New(t)
+
+	client, _ := newClient(t, cerr.ErrInvalidTenantID.Msg("tenant id must be a uuid"))
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(err)
+
+	_, err = stream.Recv()
+
+	s := status.Convert(err)
+	assert.Equal(codes.InvalidArgument, s.Code())
+
+	info := errorInfo(t, s)
+	assert.Equal("E10002", info.Reason)
+	assert.Equal("tenant id must be a uuid", info.Metadata[cerr.MessageKey])
+}
+
+func TestToStatus(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Nil(grpcerr.ToStatus(nil))
+	assert.Equal(codes.Canceled, grpcerr.ToStatus(fmt.Errorf("query: %w", context.Canceled)).Code())
+	assert.Equal(codes.DeadlineExceeded, grpcerr.ToStatus(context.DeadlineExceeded).Code())
+	assert.Equal(codes.Unavailable, grpcerr.ToStatus(cerr.ErrConnection).Code())
+}
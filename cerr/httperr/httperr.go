@@ -0,0 +1,159 @@
+// Package httperr renders errors declared in package cerr as RFC 7807 problem details.
+package httperr
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// ContentType is the media type of problem details.
+	ContentType = "application/problem+json"
+	// RequestIDHeader is the header carrying the ID of a request, read from requests and
+	// written to responses by Middleware.
+	RequestIDHeader = "X-Request-Id"
+)
+
+// Problem is the body of an error response, as described by RFC 7807.
+// Its type is always "about:blank", so it isn't serialized and Title is the HTTP status text.
+type Problem struct {
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Code is the cerr error code, e.g. "E10012".
+	Code string `json:"code"`
+	// Message is the message of the cerr error, e.g. "account not found".
+	Message string `json:"message"`
+	// Msg is the message added to the error with Msg or Msgf, under cerr.MessageKey.
+	Msg       string `json:"msg,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewProblem returns the problem details of err. Errors other than *errors.AsertoError,
+// found in the chain of err, are reported as cerr.ErrUnknown so that internal messages don't leak.
+func NewProblem(err error) *Problem {
+	var asertoErr *aerr.AsertoError
+	if !errors.As(err, &asertoErr) {
+		asertoErr = cerr.ErrUnknown
+	}
+
+	status := asertoErr.HTTPCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	return &Problem{
+		Title:   http.StatusText(status),
+		Status:  status,
+		Code:    asertoErr.Code,
+		Message: asertoErr.Message,
+		Msg:     asertoErr.Data()[cerr.MessageKey],
+	}
+}
+
+// WriteError writes the problem details of err to w, with the status of the error.
+// The request ID is taken from the response headers, where Middleware sets it.
+func WriteError(w http.ResponseWriter, err error) {
+	problem := NewProblem(err)
+	problem.RequestID = w.Header().Get(RequestIDHeader)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+type requestIDKey struct{}
+
+// RequestID returns the ID Middleware gave to the request of ctx, or "" if there's none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware gives every request an ID, taken from its X-Request-Id header or generated,
+// available with RequestID and echoed in the X-Request-Id response header.
+// It recovers panics in next, logging them with logger and responding with cerr.ErrUnknown
+// if nothing was written yet.
+func Middleware(logger *zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				logger.Error().Str("request_id", id).Str("method", r.Method).Str("path", r.URL.Path).
+					Interface("panic", recovered).Msg("recovered from panic in http handler")
+
+				if !rw.wroteHeader {
+					WriteError(w, cerr.ErrUnknown)
+				}
+			}()
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseWriter records whether the response has started, so that panics after that aren't rendered.
+type responseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, e.g. to upgrade it to a WebSocket.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("httperr: the response writer doesn't support hijacking")
+	}
+
+	w.wroteHeader = true
+	return h.Hijack()
+}
@@ -0,0 +1,167 @@
+package httperr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/cerr/httperr"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc, requestID string) (*httptest.ResponseRecorder, *bytes.Buffer) {
+	t.Helper()
+
+	log := &bytes.Buffer{}
+	logger := zerolog.New(log)
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/42", nil)
+	if requestID != "" {
+		req.Header.Set(httperr.RequestIDHeader, requestID)
+	}
+
+	rec := httptest.NewRecorder()
+	httperr.Middleware(&logger)(handler).ServeHTTP(rec, req)
+	return rec, log
+}
+
+func problem(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	require.Equal(t, httperr.ContentType, rec.Header().Get("Content-Type"))
+
+	body := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestWriteError(t *testing.T) {
+	assert := require.New(t)
+
+	rec, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		httperr.WriteError(w, fmt.Errorf("loading account: %w", cerr.ErrAccountNotFound.Msg("account 42 was deleted")))
+	}, "req-1")
+
+	assert.Equal(http.StatusNotFound, rec.Code)
+	assert.Equal("req-1", rec.Header().Get(httperr.RequestIDHeader))
+	assert.Equal(map[string]interface{}{
+		"title":      "Not Found",
+		"status":     float64(http.StatusNotFound),
+		"code":       "E10012",
+		"message":    "account not found",
+		"msg":        "account 42 was deleted",
+		"request_id": "req-1",
+	}, problem(t, rec))
+}
+
+func TestWriteUnknownError(t *testing.T) {
+	assert := require.New(t)
+
+	rec, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		httperr.WriteError(w, errors.New("dial tcp 10.0.0.3:5432: connection refused"))
+	}, "")
+
+	assert.Equal(http.StatusInternalServerError, rec.Code)
+
+	body := problem(t, rec)
+	assert.Equal(cerr.ErrUnknown.Code, body["code"])
+	assert.NotContains(rec.Body.String(), "10.0.0.3")
+	assert.NotEmpty(body["request_id"])
+	assert.Equal(rec.Header().Get(httperr.RequestIDHeader), body["request_id"])
+}
+
+func TestRequestIDInContext(t *testing.T) {
+	assert := require.New(t)
+
+	var id string
+	serve(t, func(w http.ResponseWriter, r *http.Request) {
+		id = httperr.RequestID(r.Context())
+	}, "req-2")
+
+	assert.Equal("req-2", id)
+}
+
+func TestRecoverPanic(t *testing.T) {
+	assert := require.New(t)
+
+	rec, log := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}, "req-3")
+
+	assert.Equal(http.StatusInternalServerError, rec.Code)
+	body := problem(t, rec)
+	assert.Equal(cerr.ErrUnknown.Code, body["code"])
+	assert.Equal("req-3", body["request_id"])
+
+	assert.Contains(log.String(), `"panic":"nil map"`)
+	assert.Contains(log.String(), `"request_id":"req-3"`)
+}
+
+func TestRecoverPanicAfterWrite(t *testing.T) {
+	assert := require.New(t)
+
+	rec, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("too late")
+	}, "")
+
+	assert.Equal(http.StatusAccepted, rec.Code)
+	assert.Empty(rec.Body.String())
+}
+
+func TestAbortHandlerPanics(t *testing.T) {
+	assert := require.New(t)
+
+	assert.PanicsWithValue(http.ErrAbortHandler, func() {
+		serve(t, func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}, "")
+	})
+}
+
+func TestHijack(t *testing.T) {
+	assert := require.New(t)
+
+	logger := zerolog.Nop()
+	server := httptest.NewServer(httperr.Middleware(&logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		_ = buf.Flush()
+	})))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(err)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+}
+
+func TestHijackUnsupported(t *testing.T) {
+	assert := require.New(t)
+
+	rec, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := w.(http.Hijacker).Hijack()
+		assert.Error(err)
+		w.WriteHeader(http.StatusNoContent)
+	}, "")
+
+	assert.Equal(http.StatusNoContent, rec.Code)
+}
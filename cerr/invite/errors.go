@@ -0,0 +1,38 @@
+// Package invite declares the errors about invitations to join a tenant,
+// with codes E12000 to E12999.
+package invite
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E12000 to E12999.
+var domain = registry.Reserve("invite", 12000, 12999,
+	"E10018",
+	"E10019",
+	"E10020",
+	"E10021",
+	"E10027",
+	"E10028",
+	"E10029",
+)
+
+var (
+	// Returned when an invite for an email already exists
+	ErrInviteExists = domain.New("E10018", codes.AlreadyExists, http.StatusConflict, "invite already exists")
+	// Returned when an invitation has expired
+	ErrInviteExpired = domain.New("E10019", codes.AlreadyExists, http.StatusConflict, "invite is expired")
+	// Means an existing member of a tenant was invited to join the same tenant
+	ErrAlreadyMember = domain.New("E10020", codes.AlreadyExists, http.StatusConflict, "already a tenant member")
+	// Returned if an account tried to accept or decline the invite of another account
+	ErrInviteForAnotherUser = domain.New("E10021", codes.PermissionDenied, http.StatusForbidden, "invite meant for another user")
+	// Returned when an invitation has been accepted
+	ErrInviteAlreadyAccepted = domain.New("E10027", codes.AlreadyExists, http.StatusConflict, "invite has already been accepted")
+	// Returned when an invitation has been declined
+	ErrInviteAlreadyDeclined = domain.New("E10028", codes.AlreadyExists, http.StatusConflict, "invite has already been declined")
+	// Returned when an invitation has been canceled
+	ErrInviteCanceled = domain.New("E10029", codes.AlreadyExists, http.StatusConflict, "invite has been canceled")
+)
@@ -0,0 +1,140 @@
+package cerr
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	aerr "github.com/aserto-dev/errors"
+	"golang.org/x/text/language"
+)
+
+// localeFiles holds the user-facing messages, one JSON file per language named after its
+// BCP 47 tag, mapping error codes to text/template templates of the error's fields.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// DefaultLanguage is the language of the messages when none of the requested ones is available.
+var DefaultLanguage = language.English
+
+var locales = mustLoadLocales()
+
+type localeCatalog struct {
+	matcher language.Matcher
+	// bundles holds the templates of every language, in the order of the matcher's tags.
+	bundles []map[string]*template.Template
+}
+
+// Localize returns the user-facing message of err in the language that best matches lang,
+// which can be a language tag such as "de-CH" or the value of an Accept-Language header.
+//
+// Messages are looked up by the code of the *errors.AsertoError found in the chain of err, and
+// errors without one get the message of ErrUnknown. Their templates are executed with the
+// error's fields, except MessageKey. The errors it wraps are never part of the message.
+// Messages missing from the requested language are taken from DefaultLanguage, and codes
+// without any message get the error's own message.
+func Localize(err error, lang string) string {
+	if err == nil {
+		return ""
+	}
+
+	var asertoErr *aerr.AsertoError
+	if !errors.As(err, &asertoErr) {
+		asertoErr = ErrUnknown
+	}
+
+	tags, _, _ := language.ParseAcceptLanguage(lang)
+	_, index, _ := locales.matcher.Match(tags...)
+
+	data := asertoErr.Data()
+	delete(data, MessageKey)
+
+	for _, bundle := range []map[string]*template.Template{locales.bundles[index], locales.bundles[0]} {
+		tmpl, ok := bundle[asertoErr.Code]
+		if !ok {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, data); err == nil {
+			return buf.String()
+		}
+	}
+
+	return asertoErr.Message
+}
+
+func mustLoadLocales() *localeCatalog {
+	catalog, err := loadLocales()
+	if err != nil {
+		panic(fmt.Sprintf("cerr: invalid locales: %v", err))
+	}
+	return catalog
+}
+
+func loadLocales() (*localeCatalog, error) {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []language.Tag{DefaultLanguage}
+	bundles := []map[string]*template.Template{nil}
+
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(file.Name(), path.Ext(file.Name())))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+
+		bundle, err := loadBundle(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+
+		if tag == DefaultLanguage {
+			bundles[0] = bundle
+			continue
+		}
+		tags = append(tags, tag)
+		bundles = append(bundles, bundle)
+	}
+
+	if bundles[0] == nil {
+		return nil, fmt.Errorf("no messages for the default language %s", DefaultLanguage)
+	}
+
+	return &localeCatalog{
+		matcher: language.NewMatcher(tags),
+		bundles: bundles,
+	}, nil
+}
+
+func loadBundle(name string) (map[string]*template.Template, error) {
+	b, err := localeFiles.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := map[string]string{}
+	if err := json.Unmarshal(b, &messages); err != nil {
+		return nil, err
+	}
+
+	bundle := make(map[string]*template.Template, len(messages))
+	for code, message := range messages {
+		tmpl, err := template.New(code).Parse(message)
+		if err != nil {
+			return nil, err
+		}
+		bundle[code] = tmpl
+	}
+
+	return bundle, nil
+}
@@ -0,0 +1,23 @@
+package cerr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalesCoverRegistry(t *testing.T) {
+	assert := require.New(t)
+
+	for _, err := range All() {
+		_, ok := locales.bundles[0][err.Code]
+		assert.True(ok, "no %s message for %s", DefaultLanguage, err.Code)
+	}
+
+	for _, bundle := range locales.bundles {
+		for code := range bundle {
+			_, ok := Lookup(code)
+			assert.True(ok, "message for unknown code %s", code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalize(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("This invitation was sent to another user.", cerr.Localize(cerr.ErrInviteForAnotherUser, "en"))
+	assert.Equal("Diese Einladung wurde an einen anderen Benutzer gesendet.", cerr.Localize(cerr.ErrInviteForAnotherUser, "de"))
+}
+
+func TestLocalizeLanguageMatching(t *testing.T) {
+	assert := require.New(t)
+
+	german := "Die Anfrage ist ungültig."
+	english := "The request is not valid."
+
+	assert.Equal(german, cerr.Localize(cerr.ErrInvalidArgument, "de-CH"))
+	assert.Equal(german, cerr.Localize(cerr.ErrInvalidArgument, "fr-FR,de;q=0.8,en;q=0.5"))
+	assert.Equal(english, cerr.Localize(cerr.ErrInvalidArgument, "fr"))
+	assert.Equal(english, cerr.Localize(cerr.ErrInvalidArgument, ""))
+	assert.Equal(english, cerr.Localize(cerr.ErrInvalidArgument, "not a language"))
+}
+
+func TestLocalizeFields(t *testing.T) {
+	assert := require.New(t)
+
+	err := cerr.ErrAccountNotFound.Str("account_id", "acme")
+	assert.Equal(`The account "acme" was not found.`, cerr.Localize(err, "en"))
+	assert.Equal("Das Konto „acme“ wurde nicht gefunden.", cerr.Localize(err, "de"))
+
+	assert.Equal("The account was not found.", cerr.Localize(cerr.ErrAccountNotFound, "en"))
+}
+
+func TestLocalizeHidesInternalDetails(t *testing.T) {
+	assert := require.New(t)
+
+	err := fmt.Errorf("handler: %w", cerr.ErrAccountNotFound.
+		Err(errors.New("sql: no rows in result set")).
+		Msg("select from accounts where id = 'acme'"))
+
+	message := cerr.Localize(err, "en")
+	assert.Equal("The account was not found.", message)
+	assert.NotContains(message, "sql")
+	assert.NotContains(message, "select")
+}
+
+func TestLocalizeUnknownError(t *testing.T) {
+	assert := require.New(t)
+
+	assert.Equal("Something went wrong on our side. Please try again later.",
+		cerr.Localize(errors.New("panic: runtime error"), "en"))
+	assert.Empty(cerr.Localize(nil, "en"))
+}
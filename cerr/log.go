@@ -0,0 +1,78 @@
+package cerr
+
+import (
+	"errors"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/rs/zerolog"
+)
+
+// LogObject returns a zerolog object describing err, to log it with its structure instead
+// of the single string of err.Error():
+//
+//	log.Error().Object("error", cerr.LogObject(err)).Msg("request failed")
+//
+// The object has the code, message, gRPC code, HTTP status and fields of the first
+// *errors.AsertoError of the chain of err, and the errors it wraps under "causes", outermost first.
+// Errors without an AsertoError are logged under "error", with their causes, and so are
+// errors wrapping one, in addition to its details.
+//
+// Only the last error given to AsertoError.Err is part of the chain, as it's the one returned by Unwrap.
+func LogObject(err error) zerolog.LogObjectMarshaler {
+	return logObject{err: err}
+}
+
+type logObject struct {
+	err error
+}
+
+func (o logObject) MarshalZerologObject(e *zerolog.Event) {
+	if o.err == nil {
+		return
+	}
+
+	top := o.err
+	var asertoErr *aerr.AsertoError
+	if errors.As(o.err, &asertoErr) && asertoErr != o.err {
+		// Keep the context added by the errors wrapping the AsertoError.
+		e.Str("error", o.err.Error())
+		top = asertoErr
+	}
+	marshalCause(e, top)
+
+	causes := zerolog.Arr()
+	count := 0
+	for cause := errors.Unwrap(top); cause != nil; cause = errors.Unwrap(cause) {
+		causes.Object(causeObject{err: cause})
+		count++
+	}
+	if count > 0 {
+		e.Array("causes", causes)
+	}
+}
+
+type causeObject struct {
+	err error
+}
+
+func (o causeObject) MarshalZerologObject(e *zerolog.Event) {
+	marshalCause(e, o.err)
+}
+
+// marshalCause adds err to e, without the errors it wraps.
+func marshalCause(e *zerolog.Event, err error) {
+	asertoErr, ok := err.(*aerr.AsertoError)
+	if !ok {
+		e.Str("error", err.Error())
+		return
+	}
+
+	e.Str("code", asertoErr.Code).
+		Str("message", asertoErr.Message).
+		Str("grpc_code", asertoErr.StatusCode.String()).
+		Int("http_status", asertoErr.HTTPCode)
+
+	if fields := asertoErr.Fields(); len(fields) > 0 {
+		e.Dict("fields", zerolog.Dict().Fields(fields))
+	}
+}
@@ -0,0 +1,78 @@
+package cerr_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func logError(err error) string {
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	logger.Error().Object("error", cerr.LogObject(err)).Msg("failed")
+	return buf.String()
+}
+
+func TestLogObject(t *testing.T) {
+	assert := require.New(t)
+
+	cause := cerr.WithConnectionID(cerr.ErrConnectionNotFound, "conn-1").Err(errors.New("no rows"))
+	err := cerr.WithTenant(cerr.ErrPolicyNotFound, "tenant-1").Msg("loading").Err(cause)
+
+	assert.JSONEq(`{
+		"level": "error",
+		"message": "failed",
+		"error": {
+			"code": "E10014",
+			"message": "policy not found",
+			"grpc_code": "NotFound",
+			"http_status": 404,
+			"fields": {"tenant_id": "tenant-1", "msg": "loading", "connection_id": "conn-1"},
+			"causes": [
+				{
+					"code": "E10011",
+					"message": "connection not found",
+					"grpc_code": "NotFound",
+					"http_status": 404,
+					"fields": {"connection_id": "conn-1"}
+				},
+				{"error": "no rows"}
+			]
+		}
+	}`, logError(err))
+}
+
+func TestLogObjectWrapped(t *testing.T) {
+	assert := require.New(t)
+
+	err := fmt.Errorf("fetching: %w", cerr.ErrRetryTimeout)
+
+	assert.JSONEq(`{
+		"level": "error",
+		"message": "failed",
+		"error": {
+			"error": "fetching: E10034 timeout after multiple retries",
+			"code": "E10034",
+			"message": "timeout after multiple retries",
+			"grpc_code": "DeadlineExceeded",
+			"http_status": 408
+		}
+	}`, logError(err))
+}
+
+func TestLogObjectPlainError(t *testing.T) {
+	assert := require.New(t)
+
+	assert.JSONEq(`{"level": "error", "message": "failed", "error": {"error": "boom"}}`, logError(errors.New("boom")))
+	assert.JSONEq(`{
+		"level": "error",
+		"message": "failed",
+		"error": {"error": "outer: boom", "causes": [{"error": "boom"}]}
+	}`, logError(fmt.Errorf("outer: %w", errors.New("boom"))))
+	assert.JSONEq(`{"level": "error", "message": "failed", "error": {}}`, logError(nil))
+}
@@ -0,0 +1,53 @@
+// Package policy declares the errors about policies, their sources and their builders,
+// with codes E15000 to E15999.
+package policy
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E15000 to E15999.
+var domain = registry.Reserve("policy", 15000, 15999,
+	"E10014",
+	"E10016",
+	"E10041",
+	"E10043",
+	"E10050",
+	"E10051",
+	"E10055",
+	"E10056",
+	"E10057",
+	"E10058",
+	"E10059",
+	"E10062",
+)
+
+var (
+	// Returned if a policy id is not found in the database
+	ErrPolicyNotFound = domain.New("E10014", codes.NotFound, http.StatusNotFound, "policy not found")
+	// Returned if a policy id is invalid
+	ErrInvalidPolicyID = domain.New("E10016", codes.InvalidArgument, http.StatusBadRequest, "invalid policy id")
+	// Returned when the caller tries to create or update a policy with a name that already exists
+	ErrDuplicatePolicyName = domain.New("E10041", codes.InvalidArgument, http.StatusConflict, "policy name already exists")
+	// Returned if a module is not found
+	ErrModuleNotFound = domain.New("E10043", codes.NotFound, http.StatusNotFound, "module not found")
+	// Returned if a policy builder id is not found in the database
+	ErrPolicyBuilderNotFound = domain.New("E10050", codes.NotFound, http.StatusNotFound, "policy builder not found")
+	// Returned if a policy builder id is invalid
+	ErrInvalidPolicyBuilderID = domain.New("E10051", codes.InvalidArgument, http.StatusBadRequest, "invalid policy builder id")
+	// Returned when a tag is not a valid policy
+	ErrInvalidPolicyTag = domain.New("E10055", codes.InvalidArgument, http.StatusBadRequest, "invalid policy tag")
+	// Returned if a policy instance is not found in the database
+	ErrPolicyInstanceNotFound = domain.New("E10056", codes.NotFound, http.StatusNotFound, "policy instance not found")
+	// Returned if a policy repository is not found in the database
+	ErrPolicyRepositoryNotFound = domain.New("E10057", codes.NotFound, http.StatusNotFound, "policy repository not found")
+	// Returned if a policy source is not found in the database
+	ErrPolicySourceNotFound = domain.New("E10058", codes.NotFound, http.StatusNotFound, "policy source not found")
+	// Returned if a source has already been attached to a policy
+	ErrPolicySourceAlreadySet = domain.New("E10059", codes.FailedPrecondition, http.StatusBadRequest, "source already set")
+	// Returned if a policy already has a connected repository
+	ErrPolicyRepositoryAlreadyConnected = domain.New("E10062", codes.AlreadyExists, http.StatusConflict, "the policy already has a repository connected")
+)
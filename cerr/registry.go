@@ -0,0 +1,17 @@
+package cerr
+
+import (
+	"github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr/registry"
+)
+
+// Lookup returns the error declared with code, in this package or one of its domain subpackages,
+// e.g. to turn the code of an error received over the wire back into the canonical error.
+func Lookup(code string) (*errors.AsertoError, bool) {
+	return registry.Lookup(code)
+}
+
+// All returns every error declared in this package and its domain subpackages, sorted by code.
+func All() []*errors.AsertoError {
+	return registry.All()
+}
@@ -0,0 +1,201 @@
+// Package registry records the errors declared by package cerr and its domain subpackages.
+//
+// Every domain reserves a range of error codes, and declares its errors with codes from that
+// range. The errors of the domain subpackages are also available from package cerr, which keeps
+// its original variables for compatibility.
+//
+// Codes E10000 to E10099 are the legacy block: codes up to E10072 were allocated before domains
+// existed. They are grandfathered by listing them when reserving the range of the domain that
+// declares them, so they keep their code. No range may include the legacy block, so no new error
+// can be declared in it, even with a code that was freed.
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aserto-dev/errors"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// LegacyFirst and LegacyLast bound the legacy block, which holds the codes allocated before
+	// domain ranges existed.
+	LegacyFirst = 10000
+	LegacyLast  = 10099
+)
+
+var codePattern = regexp.MustCompile(`^E(\d{5})$`)
+
+var (
+	mu      sync.RWMutex
+	ranges  []*Range
+	entries = map[string]entry{}
+	// legacy maps the grandfathered codes to the domain allowed to declare them.
+	legacy = map[string]string{}
+)
+
+type entry struct {
+	err    *errors.AsertoError
+	domain string
+}
+
+// Range is a range of error codes reserved for the errors of a domain.
+type Range struct {
+	Domain string
+	// First and Last are the numbers of the first and last codes of the range, e.g. 11000 for E11000.
+	First int
+	Last  int
+}
+
+// Reserve reserves the codes from first to last for domain, as well as the given codes of the
+// legacy block, which were declared by the domain before ranges existed.
+// It panics if the range is invalid, includes the legacy block or overlaps the range of another domain,
+// or if a legacy code isn't in the legacy block or is claimed by another domain, so conflicts fail at init time.
+func Reserve(domain string, first, last int, legacyCodes ...string) *Range {
+	if first > last || first <= LegacyLast || last > 99999 {
+		panic(fmt.Sprintf("cerr: invalid code range E%05d-E%05d for domain %s", first, last, domain))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, code := range legacyCodes {
+		if n, ok := parse(code); !ok || !isLegacy(n) {
+			panic(fmt.Sprintf("cerr: code %s of domain %s is not in the legacy block", code, domain))
+		}
+		if owner, ok := legacy[code]; ok {
+			panic(fmt.Sprintf("cerr: legacy code %s of domain %s is already claimed by domain %s", code, domain, owner))
+		}
+	}
+
+	for _, r := range ranges {
+		if r.Domain == domain {
+			panic(fmt.Sprintf("cerr: domain %s already has a code range", domain))
+		}
+		if first <= r.Last && r.First <= last {
+			panic(fmt.Sprintf("cerr: code range E%05d-E%05d of domain %s overlaps E%05d-E%05d of domain %s",
+				first, last, domain, r.First, r.Last, r.Domain))
+		}
+	}
+
+	for _, code := range legacyCodes {
+		legacy[code] = domain
+	}
+
+	r := &Range{Domain: domain, First: first, Last: last}
+	ranges = append(ranges, r)
+	return r
+}
+
+// New declares the error of code, which must be in the range, or one of the legacy codes of the domain.
+// It panics if the code is outside of the range or already used.
+func (r *Range) New(code string, statusCode codes.Code, httpCode int, msg string) *errors.AsertoError {
+	n, ok := parse(code)
+	if !ok {
+		panic(fmt.Sprintf("cerr: malformed error code %q of %q", code, msg))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !r.contains(n) && legacy[code] != r.Domain {
+		panic(fmt.Sprintf("cerr: error code %s of %q is outside of the range E%05d-E%05d of domain %s",
+			code, msg, r.First, r.Last, r.Domain))
+	}
+
+	if existing, ok := entries[code]; ok {
+		panic(fmt.Sprintf("cerr: error code %s of %q is already used by %q", code, msg, existing.err.Message))
+	}
+
+	// errors.NewAsertoError is only called once the code is known to be free, because it
+	// replaces any existing error of the same code in the errors package's own registry.
+	err := errors.NewAsertoError(code, statusCode, httpCode, msg)
+	entries[code] = entry{err: err, domain: r.Domain}
+	return err
+}
+
+// Next returns the first code of the range that isn't used yet, for declaring a new error.
+// It returns false if the range is full.
+func (r *Range) Next() (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for n := r.First; n <= r.Last; n++ {
+		code := format(n)
+		if _, ok := entries[code]; !ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+func (r *Range) contains(n int) bool {
+	return r.First <= n && n <= r.Last
+}
+
+// Lookup returns the error declared with code.
+func Lookup(code string) (*errors.AsertoError, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	e, ok := entries[code]
+	return e.err, ok
+}
+
+// Domain returns the domain that declared the error of code.
+func Domain(code string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	e, ok := entries[code]
+	return e.domain, ok
+}
+
+// All returns every declared error, sorted by code.
+func All() []*errors.AsertoError {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]*errors.AsertoError, 0, len(entries))
+	for _, e := range entries {
+		all = append(all, e.err)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Code < all[j].Code })
+	return all
+}
+
+// Ranges returns the reserved ranges, sorted by their first code.
+func Ranges() []Range {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]Range, len(ranges))
+	for i, r := range ranges {
+		result[i] = *r
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].First < result[j].First })
+	return result
+}
+
+func isLegacy(n int) bool {
+	return LegacyFirst <= n && n <= LegacyLast
+}
+
+func parse(code string) (int, bool) {
+	match := codePattern.FindStringSubmatch(code)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+func format(n int) string {
+	return fmt.Sprintf("E%05d", n)
+}
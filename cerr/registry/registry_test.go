@@ -0,0 +1,102 @@
+package registry_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRange(t *testing.T) {
+	assert := require.New(t)
+
+	r := registry.Reserve("test", 20000, 20009)
+
+	next, ok := r.Next()
+	assert.True(ok)
+	assert.Equal("E20000", next)
+
+	err := r.New("E20000", codes.NotFound, http.StatusNotFound, "test not found")
+	assert.Equal("E20000", err.Code)
+
+	found, ok := registry.Lookup("E20000")
+	assert.True(ok)
+	assert.Same(err, found)
+
+	domain, ok := registry.Domain("E20000")
+	assert.True(ok)
+	assert.Equal("test", domain)
+
+	next, ok = r.Next()
+	assert.True(ok)
+	assert.Equal("E20001", next)
+
+	assert.Contains(registry.Ranges(), registry.Range{Domain: "test", First: 20000, Last: 20009})
+}
+
+func TestRangeRejectsCodes(t *testing.T) {
+	assert := require.New(t)
+
+	r := registry.Reserve("rejecting", 20100, 20199)
+	r.New("E20100", codes.Internal, http.StatusInternalServerError, "first")
+
+	assert.PanicsWithValue(`cerr: error code E20200 of "outside" is outside of the range E20100-E20199 of domain rejecting`, func() {
+		r.New("E20200", codes.Internal, http.StatusInternalServerError, "outside")
+	})
+	assert.PanicsWithValue(`cerr: error code E20100 of "duplicate" is already used by "first"`, func() {
+		r.New("E20100", codes.Internal, http.StatusInternalServerError, "duplicate")
+	})
+	assert.PanicsWithValue(`cerr: malformed error code "20101" of "malformed"`, func() {
+		r.New("20101", codes.Internal, http.StatusInternalServerError, "malformed")
+	})
+
+	_, ok := registry.Lookup("E20200")
+	assert.False(ok)
+}
+
+func TestLegacyCodes(t *testing.T) {
+	assert := require.New(t)
+
+	r := registry.Reserve("legacy", 20300, 20399, "E10098")
+	other := registry.Reserve("other", 20900, 20999)
+
+	// Legacy codes are grandfathered in the domain listing them.
+	err := r.New("E10098", codes.Unavailable, http.StatusServiceUnavailable, "legacy")
+	assert.Equal("E10098", err.Code)
+
+	// Other legacy codes are closed to new errors, even if they are free.
+	assert.PanicsWithValue(`cerr: error code E10099 of "not listed" is outside of the range E20300-E20399 of domain legacy`, func() {
+		r.New("E10099", codes.Internal, http.StatusInternalServerError, "not listed")
+	})
+	assert.Panics(func() {
+		other.New("E10097", codes.Internal, http.StatusInternalServerError, "not listed")
+	})
+
+	assert.PanicsWithValue("cerr: legacy code E10098 of domain claiming is already claimed by domain legacy", func() {
+		registry.Reserve("claiming", 21000, 21099, "E10098")
+	})
+	assert.PanicsWithValue("cerr: code E20000 of domain claiming is not in the legacy block", func() {
+		registry.Reserve("claiming", 21000, 21099, "E20000")
+	})
+	assert.PanicsWithValue("cerr: invalid code range E10050-E10149 for domain claiming", func() {
+		registry.Reserve("claiming", 10050, 10149)
+	})
+}
+
+func TestReserveRejectsRanges(t *testing.T) {
+	assert := require.New(t)
+
+	registry.Reserve("reserved", 20400, 20499)
+
+	assert.PanicsWithValue("cerr: code range E20450-E20549 of domain overlapping overlaps E20400-E20499 of domain reserved", func() {
+		registry.Reserve("overlapping", 20450, 20549)
+	})
+	assert.PanicsWithValue("cerr: domain reserved already has a code range", func() {
+		registry.Reserve("reserved", 20500, 20599)
+	})
+	assert.Panics(func() {
+		registry.Reserve("reversed", 20699, 20600)
+	})
+}
@@ -0,0 +1,28 @@
+package cerr
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aserto-dev/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	assert := require.New(t)
+
+	assert.PanicsWithValue(`cerr: error code E10034 of "duplicate" is already used by "timeout after multiple retries"`, func() {
+		newErr("E10034", codes.Internal, http.StatusInternalServerError, "duplicate")
+	})
+
+	err, ok := Lookup("E10034")
+	assert.True(ok)
+	assert.Same(ErrRetryTimeout, err)
+	assert.Same(ErrRetryTimeout, errors.CodeToAsertoError("E10034"))
+
+	// Legacy codes of other domains are outside of the range of this package.
+	assert.PanicsWithValue(`cerr: error code E10012 of "moved" is outside of the range E10100-E10999 of domain common`, func() {
+		newErr("E10012", codes.Internal, http.StatusInternalServerError, "moved")
+	})
+}
@@ -0,0 +1,89 @@
+package cerr_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/cerr/policy"
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"github.com/aserto-dev/go-utils/cerr/runtime"
+	"github.com/aserto-dev/go-utils/cerr/tenant"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestLookup(t *testing.T) {
+	assert := require.New(t)
+
+	err, ok := cerr.Lookup("E10012")
+	assert.True(ok)
+	assert.Same(cerr.ErrAccountNotFound, err)
+
+	err, ok = cerr.Lookup("E10072")
+	assert.True(ok)
+	assert.Same(cerr.ErrDiscoveryFailed, err)
+
+	_, ok = cerr.Lookup("E99999")
+	assert.False(ok)
+}
+
+func TestLookupWireError(t *testing.T) {
+	assert := require.New(t)
+
+	// A copy carrying call-specific data, as received from another service, maps back to its canonical error.
+	received := cerr.ErrInvalidPolicyBuilderID.Msg("bad id")
+
+	err, ok := cerr.Lookup(received.Code)
+	assert.True(ok)
+	assert.Same(cerr.ErrInvalidPolicyBuilderID, err)
+	assert.Equal(codes.InvalidArgument, err.StatusCode)
+	assert.Equal(http.StatusBadRequest, err.HTTPCode)
+}
+
+func TestAllUniqueAndSorted(t *testing.T) {
+	assert := require.New(t)
+
+	all := cerr.All()
+	assert.NotEmpty(all)
+
+	for i := 1; i < len(all); i++ {
+		assert.Less(all[i-1].Code, all[i].Code)
+	}
+
+	assert.NotEqual(cerr.ErrInvalidPolicyBuilderID.Code, cerr.ErrDiscoveryFailed.Code)
+}
+
+func TestDomainPackages(t *testing.T) {
+	assert := require.New(t)
+
+	// The variables kept in cerr are the errors of the domain packages.
+	assert.Same(tenant.ErrAccountNotFound, cerr.ErrAccountNotFound)
+	assert.Same(runtime.ErrDiscoveryFailed, cerr.ErrDiscoveryFailed)
+	assert.True(policy.ErrPolicyNotFound.SameAs(cerr.ErrPolicyNotFound.Msg("policy-1")))
+
+	domains := map[string]string{}
+	for _, r := range registry.Ranges() {
+		domains[r.Domain] = fmt.Sprintf("E%05d-E%05d", r.First, r.Last)
+	}
+	assert.Equal(map[string]string{
+		"common":    "E10100-E10999",
+		"tenant":    "E11000-E11999",
+		"invite":    "E12000-E12999",
+		"scc":       "E13000-E13999",
+		"directory": "E14000-E14999",
+		"policy":    "E15000-E15999",
+		"runtime":   "E16000-E16999",
+	}, domains)
+
+	for _, err := range cerr.All() {
+		_, ok := registry.Domain(err.Code)
+		assert.True(ok, err.Code)
+	}
+
+	domain, _ := registry.Domain(cerr.ErrUnknown.Code)
+	assert.Equal("common", domain)
+	domain, _ = registry.Domain(cerr.ErrInviteExpired.Code)
+	assert.Equal("invite", domain)
+}
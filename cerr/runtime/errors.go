@@ -0,0 +1,36 @@
+// Package runtime declares the errors about policy runtimes and their queries,
+// with codes E16000 to E16999.
+package runtime
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E16000 to E16999.
+var domain = registry.Reserve("runtime", 16000, 16999,
+	"E10006",
+	"E10047",
+	"E10048",
+	"E10052",
+	"E10053",
+	"E10072",
+)
+
+var (
+	// The asked-for runtime is not yet available, but will likely be in the future.
+	ErrRuntimeLoading = domain.New("E10006", codes.Unavailable, http.StatusTooEarly, "runtime has not yet loaded")
+	// Returned when a runtime query has an error
+	ErrBadQuery = domain.New("E10047", codes.InvalidArgument, http.StatusBadRequest, "invalid query")
+	// Returned when a runtime query has an error
+	ErrQueryExecutionFailed = domain.New("E10048", codes.FailedPrecondition, http.StatusBadRequest, "query failed")
+	// Returned when a decision is invalid
+	ErrInvalidDecision = domain.New("E10052", codes.InvalidArgument, http.StatusBadRequest, "invalid decision")
+	// Returned when a runtime failed to load
+	ErrBadRuntime = domain.New("E10053", codes.Unavailable, http.StatusServiceUnavailable, "runtime loading failed")
+	// Returned when discovery for policy runtime configuration has failed.
+	// It used to share E10051 with ErrInvalidPolicyBuilderID.
+	ErrDiscoveryFailed = domain.New("E10072", codes.Unavailable, http.StatusServiceUnavailable, "discovery failed")
+)
@@ -0,0 +1,38 @@
+// Package scc declares the errors about source code control providers, such as Github and Gitlab,
+// with codes E13000 to E13999.
+package scc
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E13000 to E13999.
+var domain = registry.Reserve("scc", 13000, 13999,
+	"E10009",
+	"E10010",
+	"E10022",
+	"E10023",
+	"E10054",
+	"E10060",
+	"E10061",
+)
+
+var (
+	// Returned when there's a problem getting a github access token.
+	ErrGithubAccessToken = domain.New("E10009", codes.Unavailable, http.StatusServiceUnavailable, "failed to retrieve github access token")
+	// Returned when there's a problem communicating with an SCC provider such as Github.
+	ErrSCC = domain.New("E10010", codes.Unavailable, http.StatusServiceUnavailable, "there was an error interacting with the source code provider")
+	// Returned if an SCC repository has already been referenced in a policy
+	ErrRepoAlreadyConnected = domain.New("E10022", codes.AlreadyExists, http.StatusConflict, "repo has already been connected to a policy")
+	// Returned if there was a problem setting up a Github secret
+	ErrGithubSecret = domain.New("E10023", codes.Unavailable, http.StatusServiceUnavailable, "failed to setup repo secret")
+	// Returned when there's a problem getting a gitlab access token.
+	ErrGitlabAccessToken = domain.New("E10054", codes.Unavailable, http.StatusServiceUnavailable, "failed to retrieve gitlab access token")
+	// Returned if the organization is not found in the source code provider
+	ErrSCCOrganizationNotFound = domain.New("E10060", codes.NotFound, http.StatusNotFound, "source code control organization not found")
+	// Returned if the repo is not found in the source code provider
+	ErrSCCRepoNotFound = domain.New("E10061", codes.NotFound, http.StatusNotFound, "source code control repository not found")
+)
@@ -0,0 +1,62 @@
+// Package tenant declares the errors about tenants, accounts and users,
+// with codes E11000 to E11999.
+package tenant
+
+import (
+	"net/http"
+
+	"github.com/aserto-dev/go-utils/cerr/registry"
+	"google.golang.org/grpc/codes"
+)
+
+// domain reserves codes E11000 to E11999.
+var domain = registry.Reserve("tenant", 11000, 11999,
+	"E10001",
+	"E10002",
+	"E10003",
+	"E10012",
+	"E10013",
+	"E10024",
+	"E10025",
+	"E10026",
+	"E10031",
+	"E10033",
+	"E10044",
+	"E10045",
+	"E10049",
+	"E10068",
+	"E10071",
+)
+
+var (
+	// Means no tenant id was found in the current context
+	ErrNoTenantID = domain.New("E10001", codes.InvalidArgument, http.StatusBadRequest, "no tenant id specified")
+	// Means the tenant id is not valid
+	ErrInvalidTenantID = domain.New("E10002", codes.InvalidArgument, http.StatusBadRequest, "invalid tenant id")
+	// Means the tenant name doesn't conform to our tenant name rules
+	ErrInvalidTenantName = domain.New("E10003", codes.InvalidArgument, http.StatusBadRequest, "invalid tenant name")
+	// Returned if an account id is not found in the database
+	ErrAccountNotFound = domain.New("E10012", codes.NotFound, http.StatusNotFound, "account not found")
+	// Returned if an account id is not valid
+	ErrInvalidAccountID = domain.New("E10013", codes.InvalidArgument, http.StatusBadRequest, "invalid account id")
+	// Returned if there was a problem setting up an Auth0 user
+	ErrAuth0UserSetup = domain.New("E10024", codes.Unavailable, http.StatusServiceUnavailable, "failed to setup user")
+	// Returned if an invalid email address was used
+	ErrInvalidEmail = domain.New("E10025", codes.InvalidArgument, http.StatusBadRequest, "invalid email address")
+	// Returned if a string doesn't look like an auth0 ID
+	ErrInvalidAuth0ID = domain.New("E10026", codes.InvalidArgument, http.StatusBadRequest, "invalid auth0 ID")
+	// Means an account already exists for the specified user
+	ErrHasAccount = domain.New("E10031", codes.AlreadyExists, http.StatusConflict, "already has an account")
+	// Returned when trying to delete the last owner of a tenant
+	ErrLastOwner = domain.New("E10033", codes.PermissionDenied, http.StatusForbidden, "last owner of the tenant")
+	// Return if a user is not found
+	ErrUserNotFound = domain.New("E10044", codes.NotFound, http.StatusNotFound, "user not found")
+	// Return if a user already exists
+	ErrUserAlreadyExists = domain.New("E10045", codes.AlreadyExists, http.StatusConflict, "user already exists")
+	// Returned when the account has not setup a personal tenant yet
+	ErrPersonalTenantRequired = domain.New("E10049", codes.FailedPrecondition, http.StatusBadRequest, "personal tenant required")
+	// Returned if the tenant is marked for deletion
+	ErrTenantDeleted = domain.New("E10068", codes.NotFound, http.StatusNotFound, "tenant is marked for deletion")
+	// Returned if a tenant id is not found in the database
+	ErrTenantNotFound = domain.New("E10071", codes.NotFound, http.StatusNotFound, "tenant not found")
+)
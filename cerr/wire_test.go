@@ -0,0 +1,95 @@
+package cerr_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+// The golden file is the approved wire contract of the errors: their codes, gRPC codes,
+// HTTP statuses and messages, which clients rely on. Changes to it are reviewed like API changes.
+// After changing errors deliberately, rewrite it with:
+//
+//	go test ./cerr -run TestWireContract -update
+var update = flag.Bool("update", false, "rewrite the golden file of the error wire contract")
+
+var goldenFile = filepath.Join("testdata", "errors.golden")
+
+func TestWireContract(t *testing.T) {
+	assert := require.New(t)
+
+	actual := wireContract()
+	if *update {
+		assert.NoError(os.WriteFile(goldenFile, []byte(actual), 0o600))
+	}
+
+	golden, err := os.ReadFile(goldenFile)
+	assert.NoError(err)
+
+	if diff := diffContracts(string(golden), actual); diff != "" {
+		t.Fatalf("the wire contract of the errors changed, which breaks clients:\n%s\n"+
+			"If the change is deliberate, run go test ./cerr -run TestWireContract -update", diff)
+	}
+}
+
+// wireContract returns a line per registered error, sorted by code.
+func wireContract() string {
+	buf := &strings.Builder{}
+	buf.WriteString("# code\tgrpc\thttp\tmessage\n")
+	for _, err := range cerr.All() {
+		fmt.Fprintf(buf, "%s\t%s\t%d\t%s\n", err.Code, err.StatusCode, err.HTTPCode, err.Message)
+	}
+	return buf.String()
+}
+
+// diffContracts describes the errors added, removed or changed from golden to actual.
+func diffContracts(golden, actual string) string {
+	before, after := contractLines(golden), contractLines(actual)
+
+	var diff []string
+	for code, line := range before {
+		switch changed, ok := after[code]; {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("removed: %s", line))
+		case changed != line:
+			diff = append(diff, fmt.Sprintf("changed: %s\n     to: %s", line, changed))
+		}
+	}
+	for code, line := range after {
+		if _, ok := before[code]; !ok {
+			diff = append(diff, fmt.Sprintf("  added: %s", line))
+		}
+	}
+
+	sortByCode(diff)
+	return strings.Join(diff, "\n")
+}
+
+// contractLines returns the lines of a contract by code.
+func contractLines(contract string) map[string]string {
+	lines := map[string]string{}
+	for _, line := range strings.Split(contract, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		code, _, _ := strings.Cut(line, "\t")
+		lines[code] = line
+	}
+	return lines
+}
+
+// sortByCode sorts the lines of a diff by the code that follows their label.
+func sortByCode(diff []string) {
+	code := func(s string) string {
+		_, rest, _ := strings.Cut(s, ": ")
+		return rest
+	}
+	sort.Slice(diff, func(i, j int) bool { return code(diff[i]) < code(diff[j]) })
+}
@@ -0,0 +1,239 @@
+// Package logsample keeps hot error paths from flooding logs.
+//
+// A Sampler logs the first occurrences of an error, then only one occurrence per period,
+// with the number of occurrences suppressed since the last one logged. Errors are told apart
+// by their cerr code, or their type if they don't have one, and the place they are logged from:
+//
+//	sampler := logsample.New(logsample.Config{First: 5, Period: time.Minute})
+//	...
+//	sampler.Error(logger, err).Str("key", key).Msg("cache read failed")
+//	...
+//	sampler.Close()
+package logsample
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultFirst  = 10
+	defaultPeriod = time.Minute
+
+	// SuppressedKey is the field holding the number of occurrences that weren't logged
+	// since the last one that was.
+	SuppressedKey = "suppressed"
+)
+
+// Config configures a Sampler.
+type Config struct {
+	// First is the number of occurrences of an error logged before sampling starts. Defaults to 10.
+	First int `json:"first"`
+	// Period is the interval between the occurrences logged once sampling started. Defaults to a minute.
+	Period time.Duration `json:"period"`
+}
+
+// Sampler decides which occurrences of errors are logged. It's safe for concurrent use.
+// A nil Sampler logs every occurrence.
+type Sampler struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[key]*entry
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type key struct {
+	kind string
+	site string
+}
+
+type entry struct {
+	count      int
+	suppressed int
+	logged     time.Time
+	// logger and level are those of the last occurrence, used by Flush.
+	logger *zerolog.Logger
+	level  zerolog.Level
+}
+
+// New creates a sampler, which calls Flush every Period until it's closed.
+func New(cfg Config) *Sampler {
+	s := newSampler(cfg, time.Now)
+	go s.flushEvery(s.cfg.Period)
+	return s
+}
+
+// Close stops the periodic flushes and flushes a last time.
+func (s *Sampler) Close() {
+	if s == nil {
+		return
+	}
+
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.Flush()
+	})
+}
+
+func (s *Sampler) flushEvery(period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func newSampler(cfg Config, now func() time.Time) *Sampler {
+	if cfg.First <= 0 {
+		cfg.First = defaultFirst
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = defaultPeriod
+	}
+
+	return &Sampler{
+		cfg:     cfg,
+		now:     now,
+		entries: map[key]*entry{},
+		done:    make(chan struct{}),
+	}
+}
+
+// Error returns an error level event for err, or nil if this occurrence is suppressed.
+// Calling methods of a nil event does nothing, so the result can be used as any event.
+func (s *Sampler) Error(logger *zerolog.Logger, err error) *zerolog.Event {
+	return s.Sample(logger, zerolog.ErrorLevel, caller(), err)
+}
+
+// Warn returns a warn level event for err, or nil if this occurrence is suppressed.
+func (s *Sampler) Warn(logger *zerolog.Logger, err error) *zerolog.Event {
+	return s.Sample(logger, zerolog.WarnLevel, caller(), err)
+}
+
+// Sample returns an event at level for err, or nil if this occurrence is suppressed.
+// site stands for the call site, for callers that log errors of different origins from the
+// same place, such as the name of the operation that failed.
+//
+// The event has err, its code if it has one, and, when earlier occurrences were suppressed,
+// their number under SuppressedKey.
+func (s *Sampler) Sample(logger *zerolog.Logger, level zerolog.Level, site string, err error) *zerolog.Event {
+	if s == nil {
+		return withError(logger.WithLevel(level), err)
+	}
+
+	suppressed, ok := s.record(logger, level, key{kind: kind(err), site: site})
+	if !ok {
+		return nil
+	}
+
+	event := withError(logger.WithLevel(level), err)
+	if suppressed > 0 {
+		event = event.Int(SuppressedKey, suppressed)
+	}
+	return event
+}
+
+// record counts an occurrence of k, and reports whether it's logged, with the number of
+// occurrences suppressed since the last one logged.
+func (s *Sampler) record(logger *zerolog.Logger, level zerolog.Level, k key) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	e, ok := s.entries[k]
+	if !ok {
+		e = &entry{logged: now}
+		s.entries[k] = e
+	}
+	e.count++
+	e.logger = logger
+	e.level = level
+
+	if e.count > s.cfg.First && now.Sub(e.logged) < s.cfg.Period {
+		e.suppressed++
+		return 0, false
+	}
+
+	suppressed := e.suppressed
+	e.suppressed = 0
+	e.logged = now
+	return suppressed, true
+}
+
+// Flush logs a summary of every error that had occurrences suppressed since the last one
+// logged, so that they aren't lost when an error stops occurring. Samplers created with New
+// call it periodically and when closed.
+func (s *Sampler) Flush() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]key, 0, len(s.entries))
+	for k, e := range s.entries {
+		if e.suppressed > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].site != keys[j].site {
+			return keys[i].site < keys[j].site
+		}
+		return keys[i].kind < keys[j].kind
+	})
+
+	now := s.now()
+	for _, k := range keys {
+		e := s.entries[k]
+		e.logger.WithLevel(e.level).Str("error_kind", k.kind).Str("call_site", k.site).
+			Int(SuppressedKey, e.suppressed).Msg("suppressed repeated errors")
+		e.suppressed = 0
+		e.logged = now
+	}
+}
+
+func withError(event *zerolog.Event, err error) *zerolog.Event {
+	var asertoErr *aerr.AsertoError
+	if errors.As(err, &asertoErr) {
+		event = event.Str("code", asertoErr.Code)
+	}
+	return event.Err(err)
+}
+
+// kind returns the code of the *errors.AsertoError of the chain of err, or the type of err
+// if there's none, as the messages of other errors often hold variable data.
+func kind(err error) string {
+	var asertoErr *aerr.AsertoError
+	if errors.As(err, &asertoErr) {
+		return asertoErr.Code
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// caller returns the position of the call to the exported method that called it.
+func caller() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,178 @@
+package logsample
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNow struct {
+	t time.Time
+}
+
+func (f *fakeNow) now() time.Time {
+	return f.t
+}
+
+func lines(buf *bytes.Buffer) []string {
+	s := strings.TrimSpace(buf.String())
+	buf.Reset()
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func TestSampler(t *testing.T) {
+	assert := require.New(t)
+
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	s := newSampler(Config{First: 2, Period: time.Minute}, clock.now)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	logErr := func() {
+		s.Error(&logger, cerr.ErrConnection.Msg("down")).Msg("failed")
+	}
+
+	for i := 0; i < 5; i++ {
+		logErr()
+	}
+	logged := lines(buf)
+	assert.Len(logged, 2)
+	assert.Equal(`{"level":"error","code":"E10008","error":{"error":"E10008 connection problem: down","msg":"down"},"message":"failed"}`, logged[0])
+
+	clock.t = clock.t.Add(time.Minute)
+	logErr()
+	logErr()
+	logged = lines(buf)
+	assert.Len(logged, 1)
+	assert.Contains(logged[0], `"suppressed":3`)
+
+	clock.t = clock.t.Add(30 * time.Second)
+	logErr()
+	assert.Empty(lines(buf))
+}
+
+func TestSamplerKeys(t *testing.T) {
+	assert := require.New(t)
+
+	s := newSampler(Config{First: 1, Period: time.Minute}, (&fakeNow{t: time.Unix(0, 0)}).now)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	for i := 0; i < 3; i++ {
+		// Every code is sampled on its own.
+		s.Error(&logger, cerr.ErrConnection).Msg("failed")
+		s.Error(&logger, cerr.ErrConnectionNotFound).Msg("failed")
+		// So is every type of error, whatever the message.
+		s.Error(&logger, errors.New(strings.Repeat("x", i))).Msg("failed")
+	}
+	assert.Len(lines(buf), 3)
+
+	// And every call site.
+	s.Warn(&logger, cerr.ErrConnection).Msg("failed")
+	s.Sample(&logger, zerolog.WarnLevel, "operation", cerr.ErrConnection).Msg("failed")
+	s.Sample(&logger, zerolog.WarnLevel, "operation", cerr.ErrConnection).Msg("failed")
+	assert.Len(lines(buf), 2)
+}
+
+func TestSamplerFlush(t *testing.T) {
+	assert := require.New(t)
+
+	s := newSampler(Config{First: 1}, (&fakeNow{t: time.Unix(0, 0)}).now)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	for i := 0; i < 4; i++ {
+		s.Sample(&logger, zerolog.WarnLevel, "get", cerr.ErrConnection).Msg("failed")
+	}
+	s.Sample(&logger, zerolog.WarnLevel, "set", cerr.ErrConnection).Msg("failed")
+	assert.Len(lines(buf), 2)
+
+	s.Flush()
+	assert.Equal([]string{
+		`{"level":"warn","error_kind":"E10008","call_site":"get","suppressed":3,"message":"suppressed repeated errors"}`,
+	}, lines(buf))
+
+	s.Flush()
+	assert.Empty(lines(buf))
+}
+
+func TestNilSampler(t *testing.T) {
+	assert := require.New(t)
+
+	var s *Sampler
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	for i := 0; i < 20; i++ {
+		s.Error(&logger, errors.New("boom")).Msg("failed")
+	}
+	s.Flush()
+	assert.Len(lines(buf), 20)
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the flusher.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSamplerFlushesPeriodically(t *testing.T) {
+	assert := require.New(t)
+
+	s := New(Config{First: 1, Period: 10 * time.Millisecond})
+	defer s.Close()
+
+	buf := &syncBuffer{}
+	logger := zerolog.New(buf)
+
+	for i := 0; i < 3; i++ {
+		s.Sample(&logger, zerolog.WarnLevel, "get", cerr.ErrConnection).Msg("failed")
+	}
+
+	assert.Eventually(func() bool {
+		return strings.Contains(buf.String(), `"suppressed":2,"message":"suppressed repeated errors"`)
+	}, time.Second, time.Millisecond)
+}
+
+func TestSamplerClose(t *testing.T) {
+	assert := require.New(t)
+
+	s := New(Config{First: 1, Period: time.Hour})
+
+	buf := &syncBuffer{}
+	logger := zerolog.New(buf)
+
+	for i := 0; i < 3; i++ {
+		s.Sample(&logger, zerolog.WarnLevel, "get", cerr.ErrConnection).Msg("failed")
+	}
+
+	s.Close()
+	s.Close()
+	assert.Equal(1, strings.Count(buf.String(), `"suppressed":2`))
+}
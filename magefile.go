@@ -14,6 +14,11 @@ func init() {
 	os.Setenv("GOPRIVATE", "github.com/aserto-dev")
 }
 
+// Generate regenerates the error catalog in cerr/catalog.
+func Generate() error {
+	return common.Generate()
+}
+
 // Lint runs linting for the entire project.
 func Lint() error {
 	return common.Lint()
@@ -0,0 +1,161 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aserto-dev/go-utils/opts"
+)
+
+const (
+	defaultBudgetMaxTokens = 10
+	defaultBudgetRatio     = 0.1
+)
+
+// ErrBudgetExhausted is the reason retry loops give up when their budget is empty.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// BudgetConfig configures a Budget.
+//
+// A budget is a token bucket: every retry takes a token, and the loop gives up if there's none left.
+// Tokens are added back over time (RefillRate), and by every call making its first attempt (Ratio),
+// so that retries stay a fraction of the requests. Both can be used together. If neither is set,
+// Ratio defaults to 0.1, since a budget that is never refilled would stop retries for good once
+// a burst of failures empties it.
+type BudgetConfig struct {
+	// MaxTokens is the size of the bucket, and the number of retries allowed in a burst. Defaults to 10.
+	MaxTokens float64 `json:"max_tokens"`
+	// Ratio is the number of tokens added by every first attempt. A ratio of 0.1 allows
+	// one retry for every ten calls. Defaults to 0.1 if RefillRate isn't set either.
+	Ratio float64 `json:"ratio"`
+	// RefillRate is the number of tokens added every second.
+	RefillRate float64 `json:"refill_rate"`
+}
+
+// Budget limits the number of retries made by all the retry loops sharing it.
+// It's safe for concurrent use.
+type Budget struct {
+	name string
+	cfg  BudgetConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	tokens   float64
+	refilled time.Time
+}
+
+var (
+	budgetsMu sync.Mutex
+	budgets   = map[string]*Budget{}
+)
+
+// NewBudget creates a full budget that isn't shared by name.
+func NewBudget(cfg BudgetConfig) *Budget {
+	return newBudget("", cfg, time.Now)
+}
+
+// NamedBudget returns the budget called name, creating it with cfg if it doesn't exist yet.
+// cfg is ignored if it does, so that every caller of a downstream service can ask for the
+// service's budget and share it.
+func NamedBudget(name string, cfg BudgetConfig) *Budget {
+	budgetsMu.Lock()
+	defer budgetsMu.Unlock()
+
+	if b, ok := budgets[name]; ok {
+		return b
+	}
+
+	b := newBudget(name, cfg, time.Now)
+	budgets[name] = b
+	return b
+}
+
+func newBudget(name string, cfg BudgetConfig, now func() time.Time) *Budget {
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultBudgetMaxTokens
+	}
+	if cfg.Ratio <= 0 && cfg.RefillRate <= 0 {
+		cfg.Ratio = defaultBudgetRatio
+	}
+
+	return &Budget{
+		name:     name,
+		cfg:      cfg,
+		now:      now,
+		tokens:   cfg.MaxTokens,
+		refilled: now(),
+	}
+}
+
+// Name returns the name of the budget, empty if it was created with NewBudget.
+func (b *Budget) Name() string {
+	return b.name
+}
+
+// Tokens returns the number of tokens left.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return b.tokens
+}
+
+// deposit adds the tokens earned by a first attempt.
+func (b *Budget) deposit() {
+	if b.cfg.Ratio <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.tokens+b.cfg.Ratio, b.cfg.MaxTokens)
+}
+
+// withdraw takes a token for a retry, reporting false if there's none left.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refill adds the tokens earned since the last refill. The caller must hold b.mu.
+func (b *Budget) refill() {
+	now := b.now()
+	if b.cfg.RefillRate > 0 {
+		earned := now.Sub(b.refilled).Seconds() * b.cfg.RefillRate
+		b.tokens = math.Min(b.tokens+earned, b.cfg.MaxTokens)
+	}
+	b.refilled = now
+}
+
+// WithBudget makes the loop take a token from b for every retry, and give up with
+// ErrBudgetExhausted, without waiting, when there's none left.
+func WithBudget(b *Budget) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Budget = b
+		}
+	}
+}
+
+// allowRetry reports whether the policy's budget, if any, allows another attempt.
+func (p *Policy) allowRetry() bool {
+	return p.Budget == nil || p.Budget.withdraw()
+}
+
+// started is called before the first attempt.
+func (p *Policy) started() {
+	if p.Budget != nil {
+		p.Budget.deposit()
+	}
+}
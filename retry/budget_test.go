@@ -0,0 +1,141 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudgetExhausted(t *testing.T) {
+	assert := require.New(t)
+
+	budget := NewBudget(BudgetConfig{MaxTokens: 2})
+	attempts := 0
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		attempts++
+		return errors.New("nope")
+	}, WithBaseDelay(time.Millisecond), WithBudget(budget))
+
+	assert.ErrorIs(err, ErrBudgetExhausted)
+	assert.Equal(3, attempts)
+	assert.Equal(0.0, budget.Tokens())
+
+	// The budget is empty, so the next loop doesn't retry at all.
+	attempts = 0
+	err = RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		attempts++
+		return errors.New("nope")
+	}, WithBaseDelay(time.Millisecond), WithBudget(budget))
+
+	assert.ErrorIs(err, ErrBudgetExhausted)
+	assert.Equal(1, attempts)
+}
+
+func TestBudgetRatio(t *testing.T) {
+	assert := require.New(t)
+
+	budget := NewBudget(BudgetConfig{MaxTokens: 1, Ratio: 0.5})
+	budget.tokens = 0
+
+	for i := 0; i < 2; i++ {
+		_ = RetryContext(context.Background(), func(ctx context.Context, i int) error {
+			return nil
+		}, WithBudget(budget))
+	}
+
+	assert.Equal(1.0, budget.Tokens())
+	assert.True(budget.withdraw())
+	assert.False(budget.withdraw())
+}
+
+func TestBudgetRefill(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Now()
+	budget := newBudget("", BudgetConfig{MaxTokens: 5, RefillRate: 2}, func() time.Time { return now })
+	for budget.withdraw() {
+	}
+
+	now = now.Add(time.Second)
+	assert.Equal(2.0, budget.Tokens())
+
+	now = now.Add(time.Minute)
+	assert.Equal(5.0, budget.Tokens())
+}
+
+func TestBudgetDefaultRatio(t *testing.T) {
+	assert := require.New(t)
+
+	budget := NewBudget(BudgetConfig{MaxTokens: 1})
+	for budget.withdraw() {
+	}
+
+	// Without Ratio or RefillRate, every call earns a tenth of a retry back.
+	budget.deposit()
+	assert.InDelta(0.1, budget.Tokens(), 1e-9)
+	for i := 0; i < 10; i++ {
+		budget.deposit()
+	}
+	assert.True(budget.withdraw())
+
+	// With RefillRate only, calls don't earn tokens.
+	budget = NewBudget(BudgetConfig{MaxTokens: 1, RefillRate: 1})
+	for budget.withdraw() {
+	}
+	budget.deposit()
+	assert.Less(budget.Tokens(), 0.1)
+}
+
+func TestNamedBudget(t *testing.T) {
+	assert := require.New(t)
+
+	first := NamedBudget("test-named-budget", BudgetConfig{MaxTokens: 3})
+	second := NamedBudget("test-named-budget", BudgetConfig{MaxTokens: 100})
+
+	assert.Same(first, second)
+	assert.Equal("test-named-budget", second.Name())
+	assert.Equal(3.0, second.Tokens())
+}
+
+func TestBudgetConcurrency(t *testing.T) {
+	assert := require.New(t)
+
+	budget := NewBudget(BudgetConfig{MaxTokens: 20})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if budget.withdraw() {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(20, allowed)
+}
+
+func TestRetryBudget(t *testing.T) {
+	assert := require.New(t)
+
+	attempts := 0
+	err := Retry(5*time.Second, func(i int) error {
+		attempts++
+		return errors.New("nope")
+	}, WithBudget(NewBudget(BudgetConfig{MaxTokens: 1})))
+
+	assert.True(cerr.ErrRetryTimeout.SameAs(err))
+	assert.ErrorIs(err, ErrBudgetExhausted)
+	assert.Equal(2, attempts)
+}
@@ -0,0 +1,214 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/opts"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Class tells a retry loop what to do with an error.
+type Class int
+
+const (
+	// Unclassified leaves the decision to the next classifier. Unclassified errors are retried.
+	Unclassified Class = iota
+	// Retryable errors are retried.
+	Retryable
+	// NonRetryable errors stop the retry loop at once.
+	NonRetryable
+)
+
+// Classifier decides whether an error returned by a retried function is worth retrying.
+type Classifier func(err error) Class
+
+// DefaultClassifier stops on permanent errors and non-retryable gRPC statuses and Aserto errors,
+// and retries everything else.
+// Context errors are retried too: they may come from the timeout of a single attempt, while
+// retry loops stop on their own once their context is done.
+var DefaultClassifier = Classifiers(
+	PermanentClassifier,
+	AsertoErrorClassifier,
+	GRPCClassifier,
+	NetTimeoutClassifier,
+)
+
+// Classifiers combines classifiers. The first one that classifies an error decides.
+func Classifiers(classifiers ...Classifier) Classifier {
+	return func(err error) Class {
+		for _, classify := range classifiers {
+			if class := classify(err); class != Unclassified {
+				return class
+			}
+		}
+		return Unclassified
+	}
+}
+
+// WithClassifier sets the classifier used to decide which errors are retried.
+// It replaces DefaultClassifier, which can be combined with it using Classifiers.
+func WithClassifier(classifier Classifier) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Classifier = classifier
+		}
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err so that retry loops stop at once and return err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// unwrapPermanent returns the error wrapped by Permanent, or err if it wasn't.
+func unwrapPermanent(err error) error {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return permanent.err
+	}
+	return err
+}
+
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter wraps err so that retry loops wait d before the next attempt instead of the
+// delay computed by their policy, e.g. when a server tells how long to back off.
+// The loop gives up with cerr.ErrRetryTimeout instead if d exceeds its MaxDelay or takes it past
+// its MaxElapsedTime, and with context.DeadlineExceeded if d takes it past its context's deadline.
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, delay: d}
+}
+
+// retryAfter returns the error wrapped by RetryAfter and the delay it asked for.
+// ok is false if err wasn't wrapped by RetryAfter.
+func retryAfter(err error) (unwrapped error, delay time.Duration, ok bool) {
+	var after *retryAfterError
+	if !errors.As(err, &after) {
+		return err, 0, false
+	}
+	if err == error(after) {
+		return after.err, after.delay, true
+	}
+	return err, after.delay, true
+}
+
+// PermanentClassifier stops on errors wrapped with Permanent.
+func PermanentClassifier(err error) Class {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return NonRetryable
+	}
+	return Unclassified
+}
+
+// ContextClassifier stops on context.Canceled and context.DeadlineExceeded.
+// It isn't part of DefaultClassifier, as it would also stop on per-attempt timeouts.
+func ContextClassifier(err error) Class {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return NonRetryable
+	}
+	return Unclassified
+}
+
+// NetTimeoutClassifier retries network timeouts.
+func NetTimeoutClassifier(err error) Class {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Retryable
+	}
+	return Unclassified
+}
+
+// GRPCClassifier classifies errors carrying a gRPC status by their code.
+func GRPCClassifier(err error) Class {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return Unclassified
+	}
+	return ClassifyCode(grpcErr.GRPCStatus().Code())
+}
+
+// AsertoErrorClassifier classifies *errors.AsertoError values by their gRPC code and HTTP status.
+// They are retried if either of them says so.
+func AsertoErrorClassifier(err error) Class {
+	var asertoErr *aerr.AsertoError
+	if !errors.As(err, &asertoErr) {
+		return Unclassified
+	}
+
+	httpClass := ClassifyHTTPStatus(asertoErr.HTTPCode)
+	if httpClass == Retryable {
+		return Retryable
+	}
+	if class := ClassifyCode(asertoErr.StatusCode); class != Unclassified {
+		return class
+	}
+	return httpClass
+}
+
+// ClassifyCode classifies a gRPC status code. Unavailable, ResourceExhausted, Aborted and
+// DeadlineExceeded are retryable, codes caused by the request itself aren't.
+func ClassifyCode(code codes.Code) Class {
+	switch code {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded:
+		return Retryable
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.Unimplemented:
+		return NonRetryable
+	default:
+		return Unclassified
+	}
+}
+
+// ClassifyHTTPStatus classifies an HTTP status code. Timeouts, throttling and server errors
+// other than 501 are retryable, other client errors aren't.
+func ClassifyHTTPStatus(code int) Class {
+	switch {
+	case code == http.StatusRequestTimeout, code == http.StatusTooEarly, code == http.StatusTooManyRequests:
+		return Retryable
+	case code == http.StatusNotImplemented:
+		return NonRetryable
+	case code >= http.StatusInternalServerError:
+		return Retryable
+	case code >= http.StatusBadRequest:
+		return NonRetryable
+	default:
+		return Unclassified
+	}
+}
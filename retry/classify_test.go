@@ -0,0 +1,175 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Class
+	}{
+		{"plain", errors.New("boom"), Unclassified},
+		{"permanent", Permanent(errors.New("boom")), NonRetryable},
+		{"wrapped permanent", fmt.Errorf("calling: %w", Permanent(errors.New("boom"))), NonRetryable},
+		{"canceled", context.Canceled, Unclassified},
+		{"deadline", fmt.Errorf("calling: %w", context.DeadlineExceeded), Retryable},
+		{"attempt timeout", &url.Error{Op: "Get", URL: "http://localhost", Err: context.DeadlineExceeded}, Retryable},
+		{"net timeout", &net.OpError{Op: "dial", Err: timeoutError{}}, Retryable},
+		{"grpc unavailable", status.Error(codes.Unavailable, "down"), Retryable},
+		{"grpc permission denied", status.Error(codes.PermissionDenied, "no"), NonRetryable},
+		{"grpc internal", status.Error(codes.Internal, "oops"), Unclassified},
+		{"invalid argument", cerr.ErrInvalidArgument, NonRetryable},
+		{"wrapped invalid argument", fmt.Errorf("calling: %w", cerr.ErrInvalidArgument.Msg("bad")), NonRetryable},
+		{"not found", cerr.ErrPolicyNotFound, NonRetryable},
+		{"runtime loading", cerr.ErrRuntimeLoading, Retryable},
+		{"connection verification", cerr.ErrConnectionVerification, Retryable},
+		{"unknown", cerr.ErrUnknown, Retryable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, DefaultClassifier(test.err))
+		})
+	}
+}
+
+func TestRetryContextStopsOnNonRetryable(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		return cerr.ErrInvalidArgument.Msg("bad")
+	})
+
+	assert.Equal(1, iteration)
+	assert.True(cerr.ErrInvalidArgument.SameAs(err))
+}
+
+func TestRetryContextPermanent(t *testing.T) {
+	assert := require.New(t)
+	errBoom := errors.New("boom")
+
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		if i == 2 {
+			return Permanent(errBoom)
+		}
+		return errors.New("nope")
+	})
+
+	assert.Equal(2, iteration)
+	assert.Equal(errBoom, err)
+}
+
+func TestRetryContextCustomClassifier(t *testing.T) {
+	assert := require.New(t)
+	errFatal := errors.New("fatal")
+
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		if i == 2 {
+			return errFatal
+		}
+		return errors.New("nope")
+	}, WithClassifier(Classifiers(func(err error) Class {
+		if errors.Is(err, errFatal) {
+			return NonRetryable
+		}
+		return Unclassified
+	}, DefaultClassifier)))
+
+	assert.Equal(2, iteration)
+	assert.ErrorIs(err, errFatal)
+}
+
+func TestRetryStopsOnNonRetryable(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	start := time.Now()
+	err := Retry(5*time.Second, func(i int) error {
+		iteration = i
+		return status.Error(codes.PermissionDenied, "no")
+	})
+
+	assert.Less(time.Since(start), time.Second)
+	assert.Equal(1, iteration)
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+// attemptTimeout fails like an HTTP call whose own timeout expired.
+func attemptTimeout(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+	return &url.Error{Op: "Get", URL: "http://localhost", Err: ctx.Err()}
+}
+
+func TestRetryContextRetriesAttemptTimeouts(t *testing.T) {
+	assert := require.New(t)
+
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		if i < 3 {
+			return attemptTimeout(ctx)
+		}
+		return nil
+	}, WithBaseDelay(time.Millisecond))
+
+	assert.NoError(err)
+}
+
+func TestRetryRetriesAttemptTimeouts(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	err := Retry(2*time.Second, func(i int) error {
+		iteration = i
+		if i < 3 {
+			return attemptTimeout(context.Background())
+		}
+		return nil
+	})
+
+	assert.NoError(err)
+	assert.Equal(3, iteration)
+}
+
+func TestRetryContextStopsWhenDone(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var iteration int
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		iteration = i
+		cancel()
+		return ctx.Err()
+	}, WithBaseDelay(time.Millisecond))
+
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal(1, iteration)
+}
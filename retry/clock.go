@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/aserto-dev/go-utils/opts"
+)
+
+// Clock tells the time and waits between attempts. The real clock is used by default;
+// tests can use the fake one in package retrytest to run retry loops without sleeping.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d, returning early with ctx.Err() if ctx is done first.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// WithClock sets the clock used to measure elapsed time and to wait between attempts.
+// Context deadlines are still measured with the real clock.
+func WithClock(clock Clock) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Clock = clock
+		}
+	}
+}
+
+// clock returns the clock of the policy, or the real clock if it has none.
+func (p *Policy) clock() Clock {
+	if p.Clock == nil {
+		return realClock{}
+	}
+	return p.Clock
+}
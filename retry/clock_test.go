@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/retry/retrytest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryContextFakeClock(t *testing.T) {
+	assert := require.New(t)
+	ms := time.Millisecond
+
+	clock := retrytest.NewClock(time.Now())
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return errors.New("nope")
+	}, WithInitialDelay(5*ms), WithBaseDelay(10*ms), WithFactor(2), WithMaxDelay(50*ms), WithJitter(NoJitter),
+		WithMaxAttempts(6), WithClock(clock))
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	assert.Equal([]time.Duration{5 * ms, 10 * ms, 20 * ms, 40 * ms, 50 * ms, 50 * ms}, clock.Sleeps())
+}
+
+func TestRetryContextFakeClockJitter(t *testing.T) {
+	assert := require.New(t)
+	ms := time.Millisecond
+
+	clock := retrytest.NewClock(time.Now())
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return errors.New("nope")
+	}, WithBaseDelay(10*ms), WithFactor(2), WithJitter(EqualJitter), WithMaxAttempts(5), WithClock(clock))
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	for i, d := range clock.Sleeps() {
+		max := 10 * ms << i
+		assert.GreaterOrEqual(d, max/2)
+		assert.LessOrEqual(d, max)
+	}
+}
+
+func TestRetryFakeClockTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	clock := retrytest.NewClock(time.Now())
+	start := time.Now()
+	var iteration int
+	err := Retry(time.Minute, func(i int) error {
+		iteration = i
+		return errors.New("nope")
+	}, WithClock(clock))
+
+	assert.True(cerr.ErrRetryTimeout.SameAs(err))
+	assert.Less(time.Since(start), time.Second)
+	assert.GreaterOrEqual(clock.Elapsed(), time.Minute)
+	assert.Len(clock.Sleeps(), iteration)
+
+	// The backoff is capped at 5 seconds, with jitter never going below the 10ms minimum.
+	for _, d := range clock.Sleeps() {
+		assert.GreaterOrEqual(d, 10*time.Millisecond)
+		assert.LessOrEqual(d, 5*time.Second)
+	}
+}
+
+func TestFakeClockContextDone(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := retrytest.NewClock(time.Now())
+
+	var iteration int
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		iteration = i
+		if i == 2 {
+			cancel()
+		}
+		return errors.New("nope")
+	}, WithClock(clock))
+
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal(2, iteration)
+	assert.Len(clock.Sleeps(), 1)
+}
@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/opts"
+)
+
+// Error is returned when a retry loop gives up before the retried function succeeds.
+// It wraps both the reason the loop stopped (e.g. ctx.Err()) and the last error
+// returned by the function, so errors.Is matches either of them.
+type Error struct {
+	// Reason is why the loop stopped.
+	Reason error
+	// Last is the error returned by the last attempt. It's nil if no attempt was made.
+	Last error
+	// Attempts is the number of attempts made.
+	Attempts int
+}
+
+func (e *Error) Error() string {
+	if e.Last == nil {
+		return fmt.Sprintf("%v after %d attempts", e.Reason, e.Attempts)
+	}
+	return fmt.Sprintf("%v after %d attempts: %v", e.Reason, e.Attempts, e.Last)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *Error) Unwrap() error {
+	return e.Last
+}
+
+// Is reports whether target matches the reason the loop stopped.
+func (e *Error) Is(target error) bool {
+	return errors.Is(e.Reason, target)
+}
+
+// RetryContext calls f until it returns no error, ctx is done or the retry policy gives up.
+// The policy is DefaultPolicy() modified by params, such as WithMaxAttempts or WithPolicy.
+// RetryContext stops as soon as ctx is done, even while waiting for the next attempt.
+// f receives ctx and the attempt number, starting at 1.
+// Errors the policy's classifier deems non-retryable, such as those wrapped with Permanent,
+// are returned at once, unwrapped from Permanent.
+// When it gives up, RetryContext returns an *Error wrapping ctx.Err() (or cerr.ErrRetryTimeout
+// once the policy's attempts or time run out, or ErrBudgetExhausted once its budget does)
+// and the last error returned by f. If the policy isn't valid, the error of Validate is returned
+// without calling f.
+func RetryContext(ctx context.Context, f func(context.Context, int) error, params ...opts.Param) error {
+	return run(ctx, NewPolicy(params...), f)
+}
+
+func run(ctx context.Context, p *Policy, f func(context.Context, int) error) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	b := newSchedule(p)
+	clock := p.clock()
+	start := clock.Now()
+
+	if p.InitialDelay > 0 {
+		if err := clock.Sleep(ctx, p.InitialDelay); err != nil {
+			return p.gaveUp(0, &Error{Reason: err}, true)
+		}
+	}
+
+	p.started()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return p.gaveUp(attempt-1, &Error{Reason: ctxErr, Last: err, Attempts: attempt - 1}, true)
+		}
+
+		p.attempted()
+		err = f(ctx, attempt)
+		if err == nil {
+			p.succeeded(attempt)
+			return nil
+		}
+
+		var pushback time.Duration
+		var hasPushback bool
+		err, pushback, hasPushback = retryAfter(err)
+
+		if p.classify(err) == NonRetryable {
+			return p.gaveUp(attempt, unwrapPermanent(err), false)
+		}
+
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return p.gaveUp(attempt, &Error{Reason: cerr.ErrRetryTimeout, Last: err, Attempts: attempt}, true)
+		}
+
+		delay := b.next(attempt)
+		if hasPushback {
+			delay = pushback
+		}
+		// A server asking to wait longer than MaxDelay is given up on rather than retried early.
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			return p.gaveUp(attempt, &Error{Reason: cerr.ErrRetryTimeout, Last: err, Attempts: attempt}, true)
+		}
+		if p.MaxElapsedTime > 0 && clock.Now().Sub(start)+delay > p.MaxElapsedTime {
+			return p.gaveUp(attempt, &Error{Reason: cerr.ErrRetryTimeout, Last: err, Attempts: attempt}, true)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// Don't wait for an attempt that couldn't start before the deadline.
+			return p.gaveUp(attempt, &Error{Reason: context.DeadlineExceeded, Last: err, Attempts: attempt}, true)
+		}
+
+		if !p.allowRetry() {
+			return p.gaveUp(attempt, &Error{Reason: ErrBudgetExhausted, Last: err, Attempts: attempt}, true)
+		}
+
+		p.retrying(attempt, err, delay)
+		if sleepErr := clock.Sleep(ctx, delay); sleepErr != nil {
+			return p.gaveUp(attempt, &Error{Reason: sleepErr, Last: err, Attempts: attempt}, true)
+		}
+	}
+}
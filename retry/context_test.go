@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryContext(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		if i == 3 {
+			return nil
+		}
+		return errors.New("nope")
+	})
+
+	assert.NoError(err)
+	assert.Equal(3, iteration)
+}
+
+func TestRetryContextCancel(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errNope := errors.New("nope")
+
+	start := time.Now()
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		if i == 2 {
+			cancel()
+		}
+		return errNope
+	})
+
+	assert.Less(time.Since(start), time.Second)
+	assert.ErrorIs(err, context.Canceled)
+	assert.ErrorIs(err, errNope)
+
+	var retryErr *Error
+	assert.True(errors.As(err, &retryErr))
+	assert.Equal(2, retryErr.Attempts)
+}
+
+func TestRetryContextDeadline(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		_, ok := ctx.Deadline()
+		assert.True(ok)
+		return errors.New("nope")
+	})
+
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestRetryContextMaxAttempts(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		return errors.New("nope")
+	}, WithMaxAttempts(2))
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	assert.Equal(2, iteration)
+}
+
+func TestRetryContextAlreadyDone(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		called = true
+		return nil
+	})
+
+	assert.ErrorIs(err, context.Canceled)
+	assert.False(called)
+}
+
+func TestRetryContextRetryAfter(t *testing.T) {
+	assert := require.New(t)
+
+	var delays []time.Duration
+	last := errors.New("slow down")
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return RetryAfter(last, 5*time.Millisecond)
+	}, WithBaseDelay(time.Millisecond), WithMaxAttempts(2),
+		WithOnRetry(func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) }))
+
+	var retryErr *Error
+	assert.ErrorAs(err, &retryErr)
+	assert.Equal(last, retryErr.Last)
+	assert.Equal([]time.Duration{5 * time.Millisecond}, delays)
+}
+
+func TestRetryContextDeadlineBeforeNextAttempt(t *testing.T) {
+	assert := require.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := RetryContext(ctx, func(ctx context.Context, i int) error {
+		return RetryAfter(errors.New("nope"), 2*time.Second)
+	})
+
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Less(time.Since(start), time.Second)
+}
+
+func TestRetryContextRetryAfterExceedsMaxDelay(t *testing.T) {
+	assert := require.New(t)
+
+	var iteration int
+	start := time.Now()
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		return RetryAfter(errors.New("nope"), time.Hour)
+	}, WithMaxDelay(time.Second))
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	assert.Equal(1, iteration)
+	assert.Less(time.Since(start), time.Second)
+}
@@ -0,0 +1,29 @@
+package retry
+
+import "context"
+
+// Do calls f until it succeeds, ctx is done or policy gives up, and returns the value
+// of the successful attempt. DefaultPolicy() is used if policy is nil.
+// f receives ctx and the attempt number, starting at 1. Errors are reported as with
+// RetryContext, together with the zero value of T.
+func Do[T any](ctx context.Context, policy *Policy, f func(ctx context.Context, attempt int) (T, error)) (T, error) {
+	if policy == nil {
+		policy = NewPolicy()
+	}
+
+	var result T
+	err := run(ctx, policy, func(ctx context.Context, attempt int) error {
+		value, err := f(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		result = value
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDo(t *testing.T) {
+	assert := require.New(t)
+
+	value, err := Do(context.Background(), NewPolicy(WithBaseDelay(time.Millisecond)),
+		func(ctx context.Context, attempt int) (int, error) {
+			if attempt < 3 {
+				return attempt, errors.New("nope")
+			}
+			return attempt * 10, nil
+		})
+
+	assert.NoError(err)
+	assert.Equal(30, value)
+}
+
+func TestDoDefaultPolicy(t *testing.T) {
+	assert := require.New(t)
+
+	value, err := Do(context.Background(), nil, func(ctx context.Context, attempt int) (string, error) {
+		return "done", nil
+	})
+
+	assert.NoError(err)
+	assert.Equal("done", value)
+}
+
+func TestDoGivesUp(t *testing.T) {
+	assert := require.New(t)
+
+	value, err := Do(context.Background(), NewPolicy(WithBaseDelay(time.Millisecond), WithMaxAttempts(2)),
+		func(ctx context.Context, attempt int) (*int, error) {
+			return &attempt, errors.New("nope")
+		})
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	assert.Nil(value)
+}
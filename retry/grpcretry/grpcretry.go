@@ -0,0 +1,233 @@
+// Package grpcretry provides gRPC client interceptors that retry failed calls with the policies of package retry.
+package grpcretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/aserto-dev/go-utils/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AttemptMetadataKey is the outgoing metadata key holding the attempt number of a call, starting at 1.
+	AttemptMetadataKey = "x-retry-attempt"
+	// PushbackMetadataKey is the trailer a server sets to tell clients how many milliseconds to wait
+	// before retrying. A negative or malformed value, or one above the policy's MaxDelay, means the call
+	// must not be retried.
+	PushbackMetadataKey = "grpc-retry-pushback-ms"
+
+	// DefaultMaxAttempts is the number of attempts made by the interceptors unless params set another limit.
+	DefaultMaxAttempts = 3
+)
+
+// DefaultCodes are the status codes retried by the interceptors unless params set another classifier.
+// Other codes, such as Internal or Unknown, may come back after the server did part of the work,
+// so retrying them could repeat side effects.
+var DefaultCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+
+// CodeClassifier retries errors with one of the given status codes and stops on any other error,
+// as well as on errors wrapped with retry.Permanent and context errors.
+func CodeClassifier(retryable ...codes.Code) retry.Classifier {
+	retryableCodes := make(map[codes.Code]bool, len(retryable))
+	for _, code := range retryable {
+		retryableCodes[code] = true
+	}
+
+	return retry.Classifiers(
+		retry.PermanentClassifier,
+		retry.ContextClassifier,
+		func(err error) retry.Class {
+			var grpcErr interface{ GRPCStatus() *status.Status }
+			if errors.As(err, &grpcErr) && retryableCodes[grpcErr.GRPCStatus().Code()] {
+				return retry.Retryable
+			}
+			return retry.NonRetryable
+		},
+	)
+}
+
+// WithCodes retries the calls failing with one of the given status codes, instead of DefaultCodes.
+func WithCodes(retryable ...codes.Code) opts.Param {
+	return retry.WithClassifier(CodeClassifier(retryable...))
+}
+
+// UnaryClientInterceptor retries unary calls failing with a retryable status code, DefaultCodes
+// unless params use WithCodes or retry.WithClassifier.
+// params configure the retry policy, as with retry.RetryContext. The operation name defaults to the
+// full method name, and the number of attempts to DefaultMaxAttempts.
+// Calls stop when their context is done, so a per-call deadline bounds all the attempts together.
+// The error of the last attempt is returned, or the status of the context if it's done.
+func UnaryClientInterceptor(params ...opts.Param) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		err := retry.RetryContext(ctx, func(ctx context.Context, attempt int) error {
+			var trailer metadata.MD
+			err := invoker(withAttempt(ctx, attempt), method, req, reply, cc, append(callOpts, grpc.Trailer(&trailer))...)
+			return withPushback(err, trailer)
+		}, policy(method, params)...)
+
+		return statusError(ctx, err)
+	}
+}
+
+// StreamClientInterceptor retries opening streams failing with a retryable status code.
+// Server-streaming calls are also retried when receiving their first message fails, by opening
+// a new stream and sending the request again. Once a message has been received, errors are
+// returned as they are.
+// params configure the retry policy, as with UnaryClientInterceptor.
+func StreamClientInterceptor(params ...opts.Param) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		params := policy(method, params)
+
+		var stream grpc.ClientStream
+		err := retry.RetryContext(ctx, func(ctx context.Context, attempt int) error {
+			var err error
+			stream, err = streamer(withAttempt(ctx, attempt), desc, cc, method, callOpts...)
+			return err
+		}, params...)
+		if err != nil {
+			return nil, statusError(ctx, err)
+		}
+
+		if desc.ClientStreams || !desc.ServerStreams {
+			return stream, nil
+		}
+
+		return &serverStream{
+			ClientStream: stream,
+			ctx:          ctx,
+			open: func(ctx context.Context, attempt int) (grpc.ClientStream, error) {
+				return streamer(withAttempt(ctx, attempt), desc, cc, method, callOpts...)
+			},
+			params: params,
+		}, nil
+	}
+}
+
+// serverStream is a server-streaming grpc.ClientStream that is reopened if receiving its first message fails.
+type serverStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	open   func(ctx context.Context, attempt int) (grpc.ClientStream, error)
+	params []opts.Param
+
+	request  interface{}
+	closed   bool
+	received bool
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	s.request = m
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *serverStream) CloseSend() error {
+	s.closed = true
+	return s.ClientStream.CloseSend()
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	recvErr := s.ClientStream.RecvMsg(m)
+	if s.received || recvErr == nil || recvErr == io.EOF || s.ctx.Err() != nil {
+		s.received = s.received || recvErr == nil
+		return recvErr
+	}
+
+	err := retry.RetryContext(s.ctx, func(ctx context.Context, attempt int) error {
+		if attempt == 1 {
+			// The first attempt is the one that just failed.
+			return withPushback(recvErr, s.ClientStream.Trailer())
+		}
+
+		stream, err := s.open(ctx, attempt)
+		if err != nil {
+			return err
+		}
+		s.ClientStream = stream
+
+		if s.request != nil {
+			if err := stream.SendMsg(s.request); err != nil {
+				return err
+			}
+		}
+		if s.closed {
+			if err := stream.CloseSend(); err != nil {
+				return err
+			}
+		}
+
+		if err := stream.RecvMsg(m); err != nil {
+			if err == io.EOF {
+				return retry.Permanent(err)
+			}
+			return withPushback(err, stream.Trailer())
+		}
+		return nil
+	}, s.params...)
+	if err != nil {
+		return statusError(s.ctx, err)
+	}
+
+	s.received = true
+	return nil
+}
+
+// policy returns the retry options of a call to method: the defaults of the interceptors, then params.
+func policy(method string, params []opts.Param) []opts.Param {
+	defaults := []opts.Param{
+		retry.WithOperation(method),
+		retry.WithMaxAttempts(DefaultMaxAttempts),
+		retry.WithClassifier(CodeClassifier(DefaultCodes...)),
+	}
+	return append(defaults, params...)
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, AttemptMetadataKey, strconv.Itoa(attempt))
+}
+
+// withPushback applies the server pushback found in trailer to err.
+func withPushback(err error, trailer metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+
+	values := trailer.Get(PushbackMetadataKey)
+	if len(values) == 0 {
+		return err
+	}
+
+	ms, parseErr := strconv.Atoi(values[0])
+	if parseErr != nil || ms < 0 {
+		return retry.Permanent(err)
+	}
+	return retry.RetryAfter(err, time.Duration(ms)*time.Millisecond)
+}
+
+// statusError turns the error of a retry loop into the error the caller of a gRPC call expects:
+// the status of ctx if it's done, and the error of the last attempt otherwise, including when the
+// loop stopped early because its next attempt couldn't start before the deadline.
+func statusError(ctx context.Context, err error) error {
+	var retryErr *retry.Error
+	if !errors.As(err, &retryErr) {
+		return err
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	if retryErr.Last == nil {
+		return status.FromContextError(retryErr.Reason).Err()
+	}
+
+	return retryErr.Last
+}
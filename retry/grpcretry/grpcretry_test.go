@@ -0,0 +1,277 @@
+package grpcretry_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/aserto-dev/go-utils/retry"
+	"github.com/aserto-dev/go-utils/retry/grpcretry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// healthServer fails the first calls with the configured status and trailer.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	failures int
+	code     codes.Code
+	pushback string
+	attempts []string
+}
+
+func (s *healthServer) fail(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.attempts = append(s.attempts, md.Get(grpcretry.AttemptMetadataKey)...)
+
+	if s.failures == 0 {
+		return nil
+	}
+	s.failures--
+
+	if s.pushback != "" {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(grpcretry.PushbackMetadataKey, s.pushback))
+	}
+	return status.Error(s.code, "failing")
+}
+
+func (s *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if err := s.fail(ctx); err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	if err := s.fail(stream.Context()); err != nil {
+		return err
+	}
+	return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+func (s *healthServer) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.attempts...)
+}
+
+func newClient(t *testing.T, server *healthServer) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	return dial(t, server, retry.WithBaseDelay(time.Millisecond), retry.WithJitter(retry.NoJitter), retry.WithMaxAttempts(3))
+}
+
+func dial(t *testing.T, server *healthServer, policy ...opts.Param) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, server)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(grpcretry.UnaryClientInterceptor(policy...)),
+		grpc.WithStreamInterceptor(grpcretry.StreamClientInterceptor(policy...)),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestUnaryRetry(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 2, code: codes.Unavailable}
+	client := newClient(t, server)
+
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.NoError(err)
+	assert.Equal(grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal([]string{"1", "2", "3"}, server.calls())
+}
+
+func TestUnaryGivesUp(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 5, code: codes.Unavailable}
+	client := newClient(t, server)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Len(server.calls(), 3)
+}
+
+func TestUnaryNonRetryableCode(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.InvalidArgument}
+	client := newClient(t, server)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+	assert.Len(server.calls(), 1)
+}
+
+func TestUnaryInternalNotRetried(t *testing.T) {
+	assert := require.New(t)
+
+	for _, code := range []codes.Code{codes.Internal, codes.Unknown, codes.DataLoss} {
+		server := &healthServer{failures: 1, code: code}
+		client := newClient(t, server)
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+		assert.Equal(code, status.Code(err))
+		assert.Len(server.calls(), 1, code.String())
+	}
+}
+
+func TestUnaryWithCodes(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 5, code: codes.Internal}
+	client := dial(t, server, retry.WithBaseDelay(time.Millisecond), grpcretry.WithCodes(codes.Internal))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Internal, status.Code(err))
+	assert.Len(server.calls(), grpcretry.DefaultMaxAttempts)
+}
+
+func TestUnaryDefaultMaxAttempts(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 100, code: codes.Unavailable}
+	client := dial(t, server, retry.WithBaseDelay(time.Millisecond))
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Len(server.calls(), grpcretry.DefaultMaxAttempts)
+}
+
+func TestUnaryPushback(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.Unavailable, pushback: "100"}
+	client := newClient(t, server)
+
+	start := time.Now()
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.NoError(err)
+	assert.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+	assert.Len(server.calls(), 2)
+}
+
+func TestUnaryNegativePushback(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.Unavailable, pushback: "-1"}
+	client := newClient(t, server)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Len(server.calls(), 1)
+}
+
+func TestUnaryPushbackExceedsMaxDelay(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.Unavailable, pushback: "3600000"}
+	client := newClient(t, server)
+
+	start := time.Now()
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Less(time.Since(start), time.Second)
+	assert.Len(server.calls(), 1)
+}
+
+func TestUnaryDeadline(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.Unavailable, pushback: "1000"}
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+
+	// The next attempt couldn't start before the deadline, so the last status is kept.
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Less(time.Since(start), time.Second)
+	assert.Len(server.calls(), 1)
+}
+
+func TestUnaryCanceled(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 1, code: codes.Unavailable, pushback: "1000"}
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Equal(codes.Canceled, status.Code(err))
+	assert.Less(time.Since(start), time.Second)
+	assert.Len(server.calls(), 1)
+}
+
+func TestServerStreamRetry(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 2, code: codes.Unavailable}
+	client := newClient(t, server)
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(err)
+
+	resp, err := stream.Recv()
+	assert.NoError(err)
+	assert.Equal(grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	assert.Equal([]string{"1", "2", "3"}, server.calls())
+}
+
+func TestServerStreamGivesUp(t *testing.T) {
+	assert := require.New(t)
+
+	server := &healthServer{failures: 5, code: codes.Unavailable}
+	client := newClient(t, server)
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(err)
+
+	_, err = stream.Recv()
+	assert.Equal(codes.Unavailable, status.Code(err))
+	assert.Len(server.calls(), 3)
+}
@@ -0,0 +1,299 @@
+// Package hedge sends hedged requests: when a call is slow, a second attempt is started
+// without cancelling the first one, and whichever succeeds first wins.
+package hedge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/aserto-dev/go-utils/retry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultMaxAttempts = 2
+	defaultWindow      = 100
+	defaultMinSamples  = 10
+)
+
+// Config configures a Hedger.
+type Config struct {
+	// Delay is how long to wait for an attempt before starting the next one. It's used when
+	// Percentile is 0, and until enough latencies have been observed otherwise. It's required,
+	// as hedging every call at once would double the load.
+	Delay time.Duration `json:"delay"`
+	// Percentile, between 0 and 1, starts the next attempt once an attempt has taken longer than
+	// that percentile of the latencies observed recently, e.g. 0.95 to hedge the slowest 5% of calls.
+	Percentile float64 `json:"percentile"`
+	// MaxAttempts is the total number of attempts a call may start, including the first one. Defaults to 2.
+	MaxAttempts int `json:"max_attempts"`
+	// Window is the number of recent latencies Percentile is computed from. Defaults to 100.
+	Window int `json:"window"`
+	// MinSamples is the number of latencies to observe before using Percentile instead of Delay. Defaults to 10.
+	MinSamples int `json:"min_samples"`
+}
+
+// Hedger starts hedged attempts of calls to the same operation, and learns the latency of
+// that operation to decide when to hedge. It's safe for concurrent use.
+type Hedger struct {
+	cfg        Config
+	operation  string
+	metrics    *Metrics
+	classifier retry.Classifier
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// ErrNoDelay is returned by New when the configuration has no positive Delay.
+var ErrNoDelay = errors.New("hedge: delay must be positive")
+
+// New returns a Hedger for the operation named by WithOperation.
+// It returns ErrNoDelay if cfg.Delay isn't positive, and an error if cfg.Percentile isn't between 0 and 1.
+func New(cfg Config, params ...opts.Param) (*Hedger, error) {
+	if cfg.Delay <= 0 {
+		return nil, ErrNoDelay
+	}
+	if cfg.Percentile < 0 || cfg.Percentile > 1 {
+		return nil, fmt.Errorf("hedge: percentile %v is not between 0 and 1", cfg.Percentile)
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = defaultMinSamples
+	}
+
+	h := &Hedger{cfg: cfg, classifier: retry.DefaultClassifier}
+	for _, param := range params {
+		param(h)
+	}
+	return h, nil
+}
+
+// WithOperation names the hedged operation in metrics.
+func WithOperation(name string) opts.Param {
+	return func(o interface{}) {
+		if h, ok := o.(*Hedger); ok {
+			h.operation = name
+		}
+	}
+}
+
+// WithClassifier sets the classifier deciding which failed attempts are worth another one.
+// It replaces retry.DefaultClassifier, which can be combined with it using retry.Classifiers.
+func WithClassifier(classifier retry.Classifier) opts.Param {
+	return func(o interface{}) {
+		if h, ok := o.(*Hedger); ok {
+			h.classifier = classifier
+		}
+	}
+}
+
+// WithMetrics counts hedged calls, and the hedges that fired and won, in m.
+func WithMetrics(m *Metrics) opts.Param {
+	return func(o interface{}) {
+		if h, ok := o.(*Hedger); ok {
+			h.metrics = m
+		}
+	}
+}
+
+// Do calls f, and calls it again each time the hedger's delay passes without any attempt
+// succeeding, or an attempt fails with a retryable error, until MaxAttempts attempts have been
+// started. The value of the first successful attempt is returned and the context of the other ones
+// is cancelled. An error the hedger's classifier deems non-retryable is returned at once, and
+// if all attempts fail, the error of the last one to finish is returned.
+// f receives the attempt number, starting at 1.
+//
+// The latencies hedging learns from are those of first attempts. A first attempt still running
+// when the call ends is recorded as taking as long as the call so far, which is more than the hedge
+// delay, so that slow attempts cut short by a hedge keep counting as slow.
+func Do[T any](ctx context.Context, h *Hedger, f func(ctx context.Context, attempt int) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value   T
+		err     error
+		attempt int
+		latency time.Duration
+	}
+
+	results := make(chan result, h.cfg.MaxAttempts)
+	started := 0
+	start := func() {
+		started++
+		attempt := started
+		if attempt > 1 {
+			h.metrics.hedged(h.operation)
+		}
+
+		go func() {
+			begin := time.Now()
+			value, err := f(ctx, attempt)
+			results <- result{value: value, err: err, attempt: attempt, latency: time.Since(begin)}
+		}()
+	}
+
+	h.metrics.called(h.operation)
+	begin := time.Now()
+	start()
+
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	var zero T
+	var lastErr error
+	firstDone := false
+	for finished := 0; ; {
+		select {
+		case r := <-results:
+			finished++
+			if r.attempt == 1 {
+				firstDone = true
+			}
+			if r.err == nil {
+				switch {
+				case r.attempt == 1:
+					h.observe(r.latency)
+				case !firstDone:
+					h.observe(time.Since(begin))
+				}
+				if r.attempt > 1 {
+					h.metrics.hedgeWon(h.operation)
+				}
+				return r.value, nil
+			}
+
+			if h.classifier(r.err) == retry.NonRetryable {
+				return zero, r.err
+			}
+
+			lastErr = r.err
+			if started < h.cfg.MaxAttempts {
+				start()
+				resetTimer(timer, h.delay())
+			} else if finished == started {
+				return zero, lastErr
+			}
+		case <-timer.C:
+			if started < h.cfg.MaxAttempts {
+				start()
+				timer.Reset(h.delay())
+			}
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// delay returns how long to wait before starting the next attempt.
+func (h *Hedger) delay() time.Duration {
+	if h.cfg.Percentile <= 0 {
+		return h.cfg.Delay
+	}
+
+	h.mu.Lock()
+	if len(h.latencies) < h.cfg.MinSamples {
+		h.mu.Unlock()
+		return h.cfg.Delay
+	}
+	sorted := append([]time.Duration{}, h.latencies...)
+	h.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(math.Ceil(h.cfg.Percentile*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// observe records the latency of a first attempt.
+func (h *Hedger) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < h.cfg.Window {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % h.cfg.Window
+}
+
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+// Metrics are the Prometheus counters updated by hedgers.
+// Every counter is labelled with the operation of the hedger.
+type Metrics struct {
+	calls *prometheus.CounterVec
+	fired *prometheus.CounterVec
+	won   *prometheus.CounterVec
+}
+
+// NewMetrics creates the hedging counters and registers them with reg.
+// Counters already registered by an earlier call are reused.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	calls, err := retry.RegisterCounter(reg, "hedge_calls_total", "Number of calls made by hedgers.")
+	if err != nil {
+		return nil, err
+	}
+
+	fired, err := retry.RegisterCounter(reg, "hedge_fired_total", "Number of hedged attempts started after the first one.")
+	if err != nil {
+		return nil, err
+	}
+
+	won, err := retry.RegisterCounter(reg, "hedge_won_total", "Number of calls won by a hedged attempt rather than the first one.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		calls: calls,
+		fired: fired,
+		won:   won,
+	}, nil
+}
+
+func (m *Metrics) called(operation string) {
+	if m != nil {
+		m.calls.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *Metrics) hedged(operation string) {
+	if m != nil {
+		m.fired.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *Metrics) hedgeWon(operation string) {
+	if m != nil {
+		m.won.WithLabelValues(operation).Inc()
+	}
+}
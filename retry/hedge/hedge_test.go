@@ -0,0 +1,271 @@
+package hedge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/retry"
+	"github.com/aserto-dev/go-utils/retry/hedge"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// wait returns value after d, or the context's error if it's cancelled first.
+func wait(ctx context.Context, d time.Duration, value string) (string, error) {
+	select {
+	case <-time.After(d):
+		return value, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func newHedger(t *testing.T, cfg hedge.Config) (*hedge.Hedger, prometheus.Gatherer) {
+	t.Helper()
+
+	reg := prometheus.NewRegistry()
+	metrics, err := hedge.NewMetrics(reg)
+	require.NoError(t, err)
+
+	h, err := hedge.New(cfg, hedge.WithOperation("test"), hedge.WithMetrics(metrics))
+	require.NoError(t, err)
+
+	return h, reg
+}
+
+func TestFastCallNotHedged(t *testing.T) {
+	assert := require.New(t)
+
+	h, reg := newHedger(t, hedge.Config{Delay: time.Second})
+
+	value, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		return wait(ctx, time.Millisecond, "first")
+	})
+
+	assert.NoError(err)
+	assert.Equal("first", value)
+
+	count, err := testutil.GatherAndCount(reg, "hedge_fired_total")
+	assert.NoError(err)
+	assert.Equal(0, count)
+}
+
+func TestHedgeWins(t *testing.T) {
+	assert := require.New(t)
+
+	h, reg := newHedger(t, hedge.Config{Delay: 10 * time.Millisecond})
+
+	var cancelled int32
+	value, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 1 {
+			value, err := wait(ctx, time.Second, "first")
+			if errors.Is(err, context.Canceled) {
+				atomic.StoreInt32(&cancelled, 1)
+			}
+			return value, err
+		}
+		return wait(ctx, time.Millisecond, "hedge")
+	})
+
+	assert.NoError(err)
+	assert.Equal("hedge", value)
+	assert.Eventually(func() bool { return atomic.LoadInt32(&cancelled) == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(testutil.GatherAndCompare(reg, expected(1, 1, 1),
+		"hedge_calls_total", "hedge_fired_total", "hedge_won_total"))
+}
+
+func TestFailureStartsNextAttempt(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: time.Minute, MaxAttempts: 3})
+
+	start := time.Now()
+	value, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		if attempt < 3 {
+			return "", errors.New("nope")
+		}
+		return "third", nil
+	})
+
+	assert.NoError(err)
+	assert.Equal("third", value)
+	assert.Less(time.Since(start), time.Second)
+}
+
+func TestAllAttemptsFail(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: time.Millisecond, MaxAttempts: 3})
+
+	var calls int32
+	_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", errors.New("nope")
+	})
+
+	assert.EqualError(err, "nope")
+	assert.Equal(int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestNonRetryableFailure(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: time.Minute, MaxAttempts: 3})
+
+	errFatal := errors.New("fatal")
+	var calls int32
+	start := time.Now()
+	_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", retry.Permanent(errFatal)
+	})
+
+	assert.ErrorIs(err, errFatal)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+	assert.Less(time.Since(start), time.Second)
+}
+
+func TestNonRetryableFailureWhileHedging(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: 10 * time.Millisecond})
+
+	start := time.Now()
+	_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 1 {
+			return wait(ctx, time.Minute, "first")
+		}
+		return "", status.Error(codes.PermissionDenied, "no")
+	})
+
+	assert.Equal(codes.PermissionDenied, status.Code(err))
+	assert.Less(time.Since(start), time.Second)
+}
+
+func TestClassifier(t *testing.T) {
+	assert := require.New(t)
+
+	errFatal := errors.New("fatal")
+	h, err := hedge.New(hedge.Config{Delay: time.Minute, MaxAttempts: 3}, hedge.WithClassifier(func(err error) retry.Class {
+		if errors.Is(err, errFatal) {
+			return retry.NonRetryable
+		}
+		return retry.Unclassified
+	}))
+	assert.NoError(err)
+
+	var calls int32
+	_, err = hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", errFatal
+	})
+
+	assert.ErrorIs(err, errFatal)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestPercentileDelay(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: time.Minute, Percentile: 0.9, MinSamples: 5})
+
+	// Learn that the operation usually takes a few milliseconds.
+	for i := 0; i < 5; i++ {
+		_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+			return wait(ctx, 5*time.Millisecond, "warmup")
+		})
+		assert.NoError(err)
+	}
+
+	start := time.Now()
+	value, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		if attempt == 1 {
+			return wait(ctx, time.Minute, "first")
+		}
+		return "hedge", nil
+	})
+
+	assert.NoError(err)
+	assert.Equal("hedge", value)
+	assert.Less(time.Since(start), time.Second)
+}
+
+func TestNewRequiresDelay(t *testing.T) {
+	assert := require.New(t)
+
+	_, err := hedge.New(hedge.Config{})
+	assert.ErrorIs(err, hedge.ErrNoDelay)
+
+	_, err = hedge.New(hedge.Config{Percentile: 0.95})
+	assert.ErrorIs(err, hedge.ErrNoDelay)
+
+	_, err = hedge.New(hedge.Config{Delay: time.Millisecond, Percentile: 95})
+	assert.Error(err)
+}
+
+func TestPercentileKeepsSlowFirstAttempts(t *testing.T) {
+	assert := require.New(t)
+
+	h, reg := newHedger(t, hedge.Config{Delay: 20 * time.Millisecond, Percentile: 0.5, MinSamples: 5})
+
+	// Every first attempt is slow and cut short by a fast hedge. Were their latencies lost,
+	// the percentile would be learnt from nothing but fast calls and drop below 20ms.
+	for i := 0; i < 10; i++ {
+		_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+			if attempt == 1 {
+				return wait(ctx, time.Minute, "first")
+			}
+			return "hedge", nil
+		})
+		assert.NoError(err)
+	}
+
+	// A call taking 10ms isn't hedged, since first attempts are known to take at least 20ms.
+	_, err := hedge.Do(context.Background(), h, func(ctx context.Context, attempt int) (string, error) {
+		return wait(ctx, 10*time.Millisecond, "first")
+	})
+	assert.NoError(err)
+
+	assert.NoError(testutil.GatherAndCompare(reg, expected(11, 10, 10),
+		"hedge_calls_total", "hedge_fired_total", "hedge_won_total"))
+}
+
+func TestContextCancelled(t *testing.T) {
+	assert := require.New(t)
+
+	h, _ := newHedger(t, hedge.Config{Delay: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := hedge.Do(ctx, h, func(ctx context.Context, attempt int) (string, error) {
+		return wait(ctx, time.Minute, "never")
+	})
+
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
+
+// expected returns the exposition of the hedging counters of the "test" operation.
+func expected(calls, fired, won int) io.Reader {
+	return strings.NewReader(fmt.Sprintf(`
+# HELP hedge_calls_total Number of calls made by hedgers.
+# TYPE hedge_calls_total counter
+hedge_calls_total{operation="test"} %d
+# HELP hedge_fired_total Number of hedged attempts started after the first one.
+# TYPE hedge_fired_total counter
+hedge_fired_total{operation="test"} %d
+# HELP hedge_won_total Number of calls won by a hedged attempt rather than the first one.
+# TYPE hedge_won_total counter
+hedge_won_total{operation="test"} %d
+`, calls, fired, won))
+}
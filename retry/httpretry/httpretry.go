@@ -0,0 +1,182 @@
+// Package httpretry provides an http.RoundTripper that retries failed requests with the policies of package retry.
+package httpretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/aserto-dev/go-utils/retry"
+)
+
+// DefaultMaxAttempts is the number of attempts made by a Transport unless its params set another limit.
+const DefaultMaxAttempts = 3
+
+// Transport is an http.RoundTripper that retries idempotent requests failing with a network error,
+// 429 Too Many Requests or a 5xx status other than 501 Not Implemented.
+//
+// Requests are idempotent if their method is GET, HEAD, OPTIONS, TRACE, PUT or DELETE, or if they
+// have an Idempotency-Key header. Requests with a body are only retried if they have a GetBody
+// function to rewind it, which http.NewRequest sets for the usual body types.
+//
+// A Retry-After header replaces the delay of the retry policy. When retries are exhausted, including
+// when Retry-After exceeds the policy's MaxDelay or the deadline of the request, e.g. from
+// http.Client.Timeout, comes before the next attempt could start, RoundTrip returns
+// cerr.ErrRetryTimeout wrapping the last error, with the last status as the "status" field if the
+// last attempt got a response. The context error is only returned when the request is canceled.
+type Transport struct {
+	base   http.RoundTripper
+	params []opts.Param
+}
+
+// NewTransport returns a Transport making requests with base, or http.DefaultTransport if base is nil.
+// params configure the retry policy, as with retry.RetryContext. The operation name defaults to the
+// host of the request, and the number of attempts to DefaultMaxAttempts.
+func NewTransport(base http.RoundTripper, params ...opts.Param) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{
+		base:   base,
+		params: params,
+	}
+}
+
+// StatusError is the error of an attempt that got a retryable status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %s", e.Status)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) || !canRewind(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	params := append([]opts.Param{retry.WithOperation(req.URL.Host), retry.WithMaxAttempts(DefaultMaxAttempts)}, t.params...)
+
+	var resp *http.Response
+	err := retry.RetryContext(req.Context(), func(ctx context.Context, attempt int) error {
+		r, err := rewind(req, attempt)
+		if err != nil {
+			return retry.Permanent(err)
+		}
+
+		resp, err = t.base.RoundTrip(r)
+		if err != nil {
+			return err
+		}
+
+		if retry.ClassifyHTTPStatus(resp.StatusCode) != retry.Retryable {
+			return nil
+		}
+
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		delay, hasDelay := retryAfter(resp.Header.Get("Retry-After"))
+		discard(resp)
+		resp = nil
+
+		if hasDelay {
+			return retry.RetryAfter(statusErr, delay)
+		}
+		return statusErr
+	}, params...)
+	if err != nil {
+		return nil, exhausted(err)
+	}
+
+	return resp, nil
+}
+
+// exhausted turns the error of a retry loop into the error returned by RoundTrip.
+func exhausted(err error) error {
+	var retryErr *retry.Error
+	if !errors.As(err, &retryErr) {
+		return err
+	}
+
+	// Running out of time before the deadline is exhaustion too: only cancellation by the caller
+	// isn't, nor stopping before any attempt was made.
+	if retryErr.Last == nil || errors.Is(retryErr.Reason, context.Canceled) {
+		return retryErr
+	}
+
+	asertoErr := cerr.ErrRetryTimeout.Err(retryErr.Last).Int("attempts", retryErr.Attempts)
+
+	var statusErr *StatusError
+	if errors.As(retryErr.Last, &statusErr) {
+		asertoErr = asertoErr.Int("status", statusErr.StatusCode)
+	}
+	return asertoErr
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, hasKey := req.Header["Idempotency-Key"]
+	return hasKey
+}
+
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns the request to send for the given attempt, with a fresh body after the first one.
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// discard reads the rest of the body of a response that isn't returned, so that its connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,199 @@
+package httpretry_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	aerr "github.com/aserto-dev/errors"
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/retry"
+	"github.com/aserto-dev/go-utils/retry/httpretry"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer returns a server failing the first calls with status, and echoing the request body afterwards.
+func newServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func newClient() *http.Client {
+	return &http.Client{
+		Transport: httpretry.NewTransport(nil, retry.WithBaseDelay(time.Millisecond), retry.WithJitter(retry.NoJitter), retry.WithMaxAttempts(3)),
+	}
+}
+
+func TestRetryGet(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	resp, err := newClient().Get(server.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(int32(3), atomic.LoadInt32(calls))
+}
+
+func TestRewindBody(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 1, http.StatusBadGateway, nil)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+	assert.NoError(err)
+
+	resp, err := newClient().Do(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(err)
+	assert.Equal("payload", string(body))
+	assert.Equal(int32(2), atomic.LoadInt32(calls))
+}
+
+func TestNonIdempotentNotRetried(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 1, http.StatusServiceUnavailable, nil)
+
+	resp, err := newClient().Post(server.URL, "text/plain", strings.NewReader("payload"))
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 1, http.StatusTooManyRequests, nil)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+	assert.NoError(err)
+	req.Header.Set("Idempotency-Key", "42")
+
+	resp, err := newClient().Do(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(int32(2), atomic.LoadInt32(calls))
+}
+
+func TestClientErrorNotRetried(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 1, http.StatusNotFound, nil)
+
+	resp, err := newClient().Get(server.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+
+	start := time.Now()
+	resp, err := newClient().Get(server.URL)
+	assert.NoError(err)
+	defer resp.Body.Close()
+
+	assert.GreaterOrEqual(time.Since(start), time.Second)
+	assert.Equal(int32(2), atomic.LoadInt32(calls))
+}
+
+func TestExhausted(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 5, http.StatusInternalServerError, nil)
+
+	_, err := newClient().Get(server.URL)
+	assert.Error(err)
+	assert.Equal(int32(3), atomic.LoadInt32(calls))
+
+	var asertoErr *aerr.AsertoError
+	assert.True(errors.As(err, &asertoErr))
+	assert.True(cerr.ErrRetryTimeout.SameAs(asertoErr))
+	assert.Equal("500", asertoErr.Data()["status"])
+
+	var statusErr *httpretry.StatusError
+	assert.True(errors.As(err, &statusErr))
+	assert.Equal(http.StatusInternalServerError, statusErr.StatusCode)
+}
+
+func TestDefaultMaxAttempts(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 100, http.StatusServiceUnavailable, nil)
+	client := &http.Client{Transport: httpretry.NewTransport(nil, retry.WithBaseDelay(time.Millisecond))}
+
+	_, err := client.Get(server.URL)
+	assert.Error(err)
+	assert.Equal(int32(httpretry.DefaultMaxAttempts), atomic.LoadInt32(calls))
+}
+
+func TestExhaustedByTimeout(t *testing.T) {
+	assert := require.New(t)
+
+	server, calls := newServer(t, 5, http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"2"}})
+	client := newClient()
+	client.Timeout = time.Second
+
+	_, err := client.Get(server.URL)
+	assert.Error(err)
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+
+	var asertoErr *aerr.AsertoError
+	assert.True(errors.As(err, &asertoErr))
+	assert.True(cerr.ErrRetryTimeout.SameAs(asertoErr))
+	assert.Equal("503", asertoErr.Data()["status"])
+}
+
+func TestCanceled(t *testing.T) {
+	assert := require.New(t)
+
+	server, _ := newServer(t, 5, http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"2"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	assert.NoError(err)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err = newClient().Do(req)
+	assert.ErrorIs(err, context.Canceled)
+
+	var asertoErr *aerr.AsertoError
+	assert.False(errors.As(err, &asertoErr))
+}
@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aserto-dev/go-utils/logsample"
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// Metrics are the Prometheus counters updated by retry loops.
+// Every counter is labelled with the Operation of the policy.
+type Metrics struct {
+	attempts  *prometheus.CounterVec
+	successes *prometheus.CounterVec
+	exhausted *prometheus.CounterVec
+}
+
+// NewMetrics creates the retry counters and registers them with reg.
+// Counters already registered by an earlier call are reused, so that packages
+// sharing a registry can all call NewMetrics.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	attempts, err := RegisterCounter(reg, "retry_attempts_total", "Number of attempts made by retry loops.")
+	if err != nil {
+		return nil, err
+	}
+
+	successes, err := RegisterCounter(reg, "retry_successes_total", "Number of retry loops that succeeded.")
+	if err != nil {
+		return nil, err
+	}
+
+	exhausted, err := RegisterCounter(reg, "retry_exhausted_total",
+		"Number of retry loops that gave up on a retryable error, because of their limits or their context.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		attempts:  attempts,
+		successes: successes,
+		exhausted: exhausted,
+	}, nil
+}
+
+// RegisterCounter creates a counter labelled with "operation" and registers it with reg, returning
+// the counter already registered under name, if any, so that it can be called more than once.
+// It's shared by the packages observing retried and hedged operations.
+func RegisterCounter(reg prometheus.Registerer, name, help string) (*prometheus.CounterVec, error) {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, []string{"operation"})
+	if err := reg.Register(counter); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(*prometheus.CounterVec); ok {
+				return existing, nil
+			}
+		}
+		return nil, err
+	}
+	return counter, nil
+}
+
+// WithOperation names the retried operation in logs and metrics.
+func WithOperation(name string) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Operation = name
+		}
+	}
+}
+
+// WithOnRetry calls f after every failed attempt that will be retried,
+// with the delay before the next attempt.
+func WithOnRetry(f func(attempt int, err error, delay time.Duration)) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.OnRetry = f
+		}
+	}
+}
+
+// WithOnGiveUp calls f when the loop stops without success,
+// with the number of attempts made and the error the loop returns.
+func WithOnGiveUp(f func(attempts int, err error)) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.OnGiveUp = f
+		}
+	}
+}
+
+// WithLogger logs failed attempts at debug level and give-ups at warn level.
+func WithLogger(logger *zerolog.Logger) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Logger = logger
+		}
+	}
+}
+
+// WithLogSampler samples the give-ups logged with WithLogger, so that an operation failing
+// repeatedly doesn't flood the logs. Give-ups are told apart by error code and operation.
+func WithLogSampler(s *logsample.Sampler) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.LogSampler = s
+		}
+	}
+}
+
+// WithMetrics counts attempts, successes and exhaustions in m.
+func WithMetrics(m *Metrics) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Metrics = m
+		}
+	}
+}
+
+// attempted is called before every attempt.
+func (p *Policy) attempted() {
+	if p.Metrics != nil {
+		p.Metrics.attempts.WithLabelValues(p.Operation).Inc()
+	}
+}
+
+// succeeded is called when an attempt succeeds.
+func (p *Policy) succeeded(attempt int) {
+	if p.Metrics != nil {
+		p.Metrics.successes.WithLabelValues(p.Operation).Inc()
+	}
+
+	if p.Logger != nil && attempt > 1 {
+		p.Logger.Debug().Str("operation", p.Operation).Int("attempt", attempt).Msg("retry succeeded")
+	}
+}
+
+// retrying is called after a failed attempt, before sleeping for delay.
+func (p *Policy) retrying(attempt int, err error, delay time.Duration) {
+	if p.Logger != nil {
+		p.Logger.Debug().Str("operation", p.Operation).Int("attempt", attempt).Dur("delay", delay).Err(err).
+			Msg("attempt failed, retrying")
+	}
+
+	if p.OnRetry != nil {
+		p.OnRetry(attempt, err, delay)
+	}
+}
+
+// gaveUp is called when the loop returns err after the given number of attempts.
+// exhausted is false if it stopped because err isn't retryable.
+func (p *Policy) gaveUp(attempts int, err error, exhausted bool) error {
+	if p.Metrics != nil && exhausted {
+		p.Metrics.exhausted.WithLabelValues(p.Operation).Inc()
+	}
+
+	if p.Logger != nil {
+		p.LogSampler.Sample(p.Logger, zerolog.WarnLevel, p.Operation, err).
+			Str("operation", p.Operation).Int("attempts", attempts).Bool("exhausted", exhausted).
+			Msg("giving up retrying")
+	}
+
+	if p.OnGiveUp != nil {
+		p.OnGiveUp(attempts, err)
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/logsample"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryContextHooks(t *testing.T) {
+	assert := require.New(t)
+
+	var retries []int
+	var delays []time.Duration
+	gaveUp := 0
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return errors.New("nope")
+	},
+		WithStrategy(Constant), WithBaseDelay(time.Millisecond), WithJitter(NoJitter), WithMaxAttempts(3),
+		WithOnRetry(func(attempt int, err error, delay time.Duration) {
+			retries = append(retries, attempt)
+			delays = append(delays, delay)
+		}),
+		WithOnGiveUp(func(attempts int, err error) {
+			gaveUp = attempts
+			assert.ErrorIs(err, cerr.ErrRetryTimeout)
+		}),
+	)
+
+	assert.Error(err)
+	assert.Equal([]int{1, 2}, retries)
+	assert.Equal([]time.Duration{time.Millisecond, time.Millisecond}, delays)
+	assert.Equal(3, gaveUp)
+}
+
+func TestRetryContextLogger(t *testing.T) {
+	assert := require.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		if i == 2 {
+			return nil
+		}
+		return errors.New("nope")
+	}, WithBaseDelay(time.Millisecond), WithJitter(NoJitter), WithOperation("fetch"), WithLogger(&logger))
+
+	assert.NoError(err)
+	assert.Contains(buf.String(), `"operation":"fetch","attempt":1,"delay":1,"error":"nope","message":"attempt failed, retrying"`)
+	assert.Contains(buf.String(), `"attempt":2,"message":"retry succeeded"`)
+}
+
+func TestRetryContextMetrics(t *testing.T) {
+	assert := require.New(t)
+
+	reg := prometheus.NewRegistry()
+	metrics, err := NewMetrics(reg)
+	assert.NoError(err)
+
+	again, err := NewMetrics(reg)
+	assert.NoError(err)
+
+	err = RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		if i == 3 {
+			return nil
+		}
+		return errors.New("nope")
+	}, WithBaseDelay(time.Millisecond), WithOperation("ok"), WithMetrics(metrics))
+	assert.NoError(err)
+
+	err = RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return errors.New("nope")
+	}, WithBaseDelay(time.Millisecond), WithMaxAttempts(2), WithOperation("failing"), WithMetrics(again))
+	assert.Error(err)
+
+	err = RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		return Permanent(errors.New("nope"))
+	}, WithOperation("failing"), WithMetrics(metrics))
+	assert.Error(err)
+
+	assert.Equal(3.0, testutil.ToFloat64(metrics.attempts.WithLabelValues("ok")))
+	assert.Equal(1.0, testutil.ToFloat64(metrics.successes.WithLabelValues("ok")))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.exhausted.WithLabelValues("ok")))
+
+	assert.Equal(3.0, testutil.ToFloat64(metrics.attempts.WithLabelValues("failing")))
+	assert.Equal(0.0, testutil.ToFloat64(metrics.successes.WithLabelValues("failing")))
+	assert.Equal(1.0, testutil.ToFloat64(metrics.exhausted.WithLabelValues("failing")))
+}
+
+func TestRetryHooks(t *testing.T) {
+	assert := require.New(t)
+
+	retries := 0
+	gaveUp := 0
+	err := Retry(50*time.Millisecond, func(i int) error {
+		return errors.New("nope")
+	},
+		WithOnRetry(func(attempt int, err error, delay time.Duration) { retries++ }),
+		WithOnGiveUp(func(attempts int, err error) { gaveUp = attempts }),
+	)
+
+	assert.Error(err)
+	assert.Greater(retries, 0)
+	assert.Equal(retries, gaveUp)
+}
+
+func TestRetryContextLogSampler(t *testing.T) {
+	assert := require.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := zerolog.New(buf)
+	sampler := logsample.New(logsample.Config{First: 2})
+
+	for i := 0; i < 5; i++ {
+		err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+			return Permanent(cerr.ErrConnection)
+		}, WithOperation("connect"), WithLogger(&logger), WithLogSampler(sampler))
+		assert.Error(err)
+	}
+
+	assert.Equal(2, strings.Count(buf.String(), "giving up retrying"))
+	assert.Contains(buf.String(), `"code":"E10008"`)
+}
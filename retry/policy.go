@@ -0,0 +1,386 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aserto-dev/go-utils/logsample"
+	"github.com/aserto-dev/go-utils/opts"
+	"github.com/rs/zerolog"
+)
+
+// Strategy is the way delays grow between attempts.
+type Strategy int
+
+const (
+	// Exponential multiplies the delay by Factor after every attempt.
+	Exponential Strategy = iota
+	// Constant waits BaseDelay between attempts.
+	Constant
+	// Linear adds BaseDelay to the delay after every attempt.
+	Linear
+	// DecorrelatedJitter picks a random delay between BaseDelay and three times the
+	// previous delay, as described in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+	DecorrelatedJitter
+)
+
+var strategyToString = map[Strategy]string{
+	Exponential:        "exponential",
+	Constant:           "constant",
+	Linear:             "linear",
+	DecorrelatedJitter: "decorrelated_jitter",
+}
+
+var strategyToID = map[string]Strategy{
+	"exponential":         Exponential,
+	"constant":            Constant,
+	"linear":              Linear,
+	"decorrelated_jitter": DecorrelatedJitter,
+}
+
+func (s Strategy) String() string {
+	return strategyToString[s]
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (s Strategy) MarshalJSON() ([]byte, error) {
+	return marshalEnum(strategyToString[s])
+}
+
+// UnmarshalJSON un-marshals a quoted json string to the enum value
+func (s *Strategy) UnmarshalJSON(b []byte) error {
+	var j string
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	var ok bool
+	*s, ok = strategyToID[j]
+	if !ok {
+		return fmt.Errorf("'%s' is not a valid retry strategy", j)
+	}
+	return nil
+}
+
+// Jitter is the way delays are randomized, so that clients failing together don't retry together.
+// It doesn't apply to DecorrelatedJitter, which is randomized already.
+type Jitter int
+
+const (
+	// NoJitter uses the computed delays as they are.
+	NoJitter Jitter = iota
+	// FullJitter picks a random delay between 0 and the computed delay.
+	FullJitter
+	// EqualJitter picks a random delay between half the computed delay and the computed delay.
+	EqualJitter
+)
+
+var jitterToString = map[Jitter]string{
+	NoJitter:    "none",
+	FullJitter:  "full",
+	EqualJitter: "equal",
+}
+
+var jitterToID = map[string]Jitter{
+	"none":  NoJitter,
+	"full":  FullJitter,
+	"equal": EqualJitter,
+}
+
+func (j Jitter) String() string {
+	return jitterToString[j]
+}
+
+// MarshalJSON marshals the enum as a quoted json string
+func (j Jitter) MarshalJSON() ([]byte, error) {
+	return marshalEnum(jitterToString[j])
+}
+
+// UnmarshalJSON un-marshals a quoted json string to the enum value
+func (j *Jitter) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	var ok bool
+	*j, ok = jitterToID[s]
+	if !ok {
+		return fmt.Errorf("'%s' is not a valid jitter type", s)
+	}
+	return nil
+}
+
+func marshalEnum(s string) ([]byte, error) {
+	buffer := bytes.NewBufferString(`"`)
+	buffer.WriteString(s)
+	buffer.WriteString(`"`)
+	return buffer.Bytes(), nil
+}
+
+// Policy describes how a retry loop waits between attempts and when it gives up.
+//
+// Policies can be loaded from JSON, with durations written as strings such as "250ms":
+//
+//	{"strategy": "exponential", "base_delay": "10ms", "max_delay": "5s", "factor": 1.5,
+//	 "jitter": "equal", "max_attempts": 10, "max_elapsed_time": "30s"}
+//
+// Fields missing from the JSON keep their current value, so unmarshal into
+// DefaultPolicy() to only override some of them.
+type Policy struct {
+	Strategy Strategy
+	// InitialDelay is how long to wait before the first attempt.
+	InitialDelay time.Duration
+	// BaseDelay is the delay after the first failed attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts. There's no cap if 0.
+	MaxDelay time.Duration
+	// Factor is the multiplier of the Exponential strategy.
+	Factor float64
+	Jitter Jitter
+	// MaxAttempts stops the loop after that many attempts. There's no limit if 0.
+	MaxAttempts int
+	// MaxElapsedTime stops the loop when the next attempt would start after that much
+	// time since the loop started. There's no limit if 0.
+	MaxElapsedTime time.Duration
+	// Classifier decides which errors are retried. DefaultClassifier is used if nil.
+	// It can't be set from JSON.
+	Classifier Classifier
+	// Budget, if set, is shared with other loops and limits the number of retries they make together.
+	// It can't be set from JSON.
+	Budget *Budget
+	// Clock measures elapsed time and waits between attempts. The real clock is used if nil.
+	// It can't be set from JSON.
+	Clock Clock
+
+	// The fields below observe the loop. They can't be set from JSON.
+
+	// Operation names the retried operation in logs and metrics.
+	Operation string
+	// OnRetry is called after every failed attempt that will be retried, with the delay before the next one.
+	OnRetry func(attempt int, err error, delay time.Duration)
+	// OnGiveUp is called when the loop stops without success, with the number of attempts made and the error returned.
+	OnGiveUp func(attempts int, err error)
+	// Logger logs failed attempts and give-ups. Nothing is logged if nil.
+	Logger *zerolog.Logger
+	// Metrics counts attempts, successes and exhaustions. Nothing is counted if nil.
+	Metrics *Metrics
+	// LogSampler, if set, samples the give-ups logged by Logger, by error code and operation.
+	LogSampler *logsample.Sampler
+}
+
+// classify returns the class of err according to the policy's classifier.
+func (p *Policy) classify(err error) Class {
+	if p.Classifier == nil {
+		return DefaultClassifier(err)
+	}
+	return p.Classifier(err)
+}
+
+// DefaultPolicy returns the policy used by RetryContext when no options are given:
+// an exponential backoff starting at 10ms with a factor of 1.5, capped at 5 seconds.
+func DefaultPolicy() Policy {
+	return Policy{
+		Strategy:  Exponential,
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  5 * time.Second,
+		Factor:    1.5,
+		Jitter:    EqualJitter,
+	}
+}
+
+// Validate reports why the policy can't be used: BaseDelay must be positive, Factor at least 1,
+// and the other durations and MaxAttempts can't be negative. A zero BaseDelay would turn the
+// retry loop into a busy loop.
+func (p *Policy) Validate() error {
+	switch {
+	case p.BaseDelay <= 0:
+		return fmt.Errorf("invalid retry policy: base delay %v is not positive", p.BaseDelay)
+	case p.InitialDelay < 0:
+		return fmt.Errorf("invalid retry policy: initial delay %v is negative", p.InitialDelay)
+	case p.MaxDelay < 0:
+		return fmt.Errorf("invalid retry policy: max delay %v is negative", p.MaxDelay)
+	case p.MaxElapsedTime < 0:
+		return fmt.Errorf("invalid retry policy: max elapsed time %v is negative", p.MaxElapsedTime)
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("invalid retry policy: max attempts %d is negative", p.MaxAttempts)
+	case p.Factor < 1:
+		return fmt.Errorf("invalid retry policy: factor %v is less than 1", p.Factor)
+	}
+	return nil
+}
+
+// NewPolicy returns the default policy modified by params.
+// Retry loops check it with Validate before the first attempt.
+func NewPolicy(params ...opts.Param) *Policy {
+	p := DefaultPolicy()
+	for _, param := range params {
+		param(&p)
+	}
+	return &p
+}
+
+// WithPolicy replaces the whole policy. Options after it modify the given policy.
+func WithPolicy(policy Policy) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			*p = policy
+		}
+	}
+}
+
+// WithStrategy sets the way delays grow between attempts.
+func WithStrategy(strategy Strategy) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Strategy = strategy
+		}
+	}
+}
+
+// WithInitialDelay waits d before the first attempt.
+func WithInitialDelay(d time.Duration) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.InitialDelay = d
+		}
+	}
+}
+
+// WithBaseDelay sets the delay after the first failed attempt.
+func WithBaseDelay(d time.Duration) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.BaseDelay = d
+		}
+	}
+}
+
+// WithMaxDelay caps the delay between attempts.
+func WithMaxDelay(d time.Duration) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.MaxDelay = d
+		}
+	}
+}
+
+// WithFactor sets the multiplier of the Exponential strategy.
+func WithFactor(factor float64) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Factor = factor
+		}
+	}
+}
+
+// WithJitter sets the way delays are randomized.
+func WithJitter(jitter Jitter) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.Jitter = jitter
+		}
+	}
+}
+
+// WithMaxAttempts stops retrying after n attempts. There's no limit if n is 0.
+func WithMaxAttempts(n int) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.MaxAttempts = n
+		}
+	}
+}
+
+// WithMaxElapsedTime stops retrying when the next attempt would start more than d after the first one.
+func WithMaxElapsedTime(d time.Duration) opts.Param {
+	return func(o interface{}) {
+		if p, ok := o.(*Policy); ok {
+			p.MaxElapsedTime = d
+		}
+	}
+}
+
+// policyJSON is the JSON representation of a Policy.
+type policyJSON struct {
+	Strategy       Strategy     `json:"strategy"`
+	InitialDelay   jsonDuration `json:"initial_delay"`
+	BaseDelay      jsonDuration `json:"base_delay"`
+	MaxDelay       jsonDuration `json:"max_delay"`
+	Factor         float64      `json:"factor"`
+	Jitter         Jitter       `json:"jitter"`
+	MaxAttempts    int          `json:"max_attempts"`
+	MaxElapsedTime jsonDuration `json:"max_elapsed_time"`
+}
+
+// MarshalJSON marshals the policy with durations as strings.
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&policyJSON{
+		Strategy:       p.Strategy,
+		InitialDelay:   jsonDuration(p.InitialDelay),
+		BaseDelay:      jsonDuration(p.BaseDelay),
+		MaxDelay:       jsonDuration(p.MaxDelay),
+		Factor:         p.Factor,
+		Jitter:         p.Jitter,
+		MaxAttempts:    p.MaxAttempts,
+		MaxElapsedTime: jsonDuration(p.MaxElapsedTime),
+	})
+}
+
+// UnmarshalJSON un-marshals a policy, leaving the fields missing from b unchanged.
+// It fails if the resulting policy isn't valid.
+func (p *Policy) UnmarshalJSON(b []byte) error {
+	j := policyJSON{
+		Strategy:       p.Strategy,
+		InitialDelay:   jsonDuration(p.InitialDelay),
+		BaseDelay:      jsonDuration(p.BaseDelay),
+		MaxDelay:       jsonDuration(p.MaxDelay),
+		Factor:         p.Factor,
+		Jitter:         p.Jitter,
+		MaxAttempts:    p.MaxAttempts,
+		MaxElapsedTime: jsonDuration(p.MaxElapsedTime),
+	}
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+
+	p.Strategy = j.Strategy
+	p.InitialDelay = time.Duration(j.InitialDelay)
+	p.BaseDelay = time.Duration(j.BaseDelay)
+	p.MaxDelay = time.Duration(j.MaxDelay)
+	p.Factor = j.Factor
+	p.Jitter = j.Jitter
+	p.MaxAttempts = j.MaxAttempts
+	p.MaxElapsedTime = time.Duration(j.MaxElapsedTime)
+	return p.Validate()
+}
+
+// jsonDuration is a time.Duration written in JSON as a string ("1.5s"). Numbers are read as nanoseconds.
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch value := v.(type) {
+	case float64:
+		*d = jsonDuration(time.Duration(value))
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = jsonDuration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", string(b))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/retry/retrytest"
+	"github.com/stretchr/testify/require"
+)
+
+func delays(p *Policy, attempts int) []time.Duration {
+	s := newSchedule(p)
+	result := make([]time.Duration, attempts)
+	for i := range result {
+		result[i] = s.next(i + 1)
+	}
+	return result
+}
+
+func TestScheduleStrategies(t *testing.T) {
+	assert := require.New(t)
+	ms := time.Millisecond
+
+	constant := NewPolicy(WithStrategy(Constant), WithBaseDelay(10*ms), WithJitter(NoJitter))
+	assert.Equal([]time.Duration{10 * ms, 10 * ms, 10 * ms}, delays(constant, 3))
+
+	linear := NewPolicy(WithStrategy(Linear), WithBaseDelay(10*ms), WithMaxDelay(25*ms), WithJitter(NoJitter))
+	assert.Equal([]time.Duration{10 * ms, 20 * ms, 25 * ms}, delays(linear, 3))
+
+	exponential := NewPolicy(WithStrategy(Exponential), WithBaseDelay(10*ms), WithFactor(2), WithMaxDelay(50*ms), WithJitter(NoJitter))
+	assert.Equal([]time.Duration{10 * ms, 20 * ms, 40 * ms, 50 * ms}, delays(exponential, 4))
+}
+
+func TestScheduleDecorrelatedJitter(t *testing.T) {
+	assert := require.New(t)
+
+	p := NewPolicy(WithStrategy(DecorrelatedJitter), WithBaseDelay(10*time.Millisecond), WithMaxDelay(time.Second))
+	s := newSchedule(p)
+
+	prev := p.BaseDelay
+	for i := 1; i <= 100; i++ {
+		d := s.next(i)
+		assert.GreaterOrEqual(d, p.BaseDelay)
+		assert.LessOrEqual(d, 3*prev)
+		assert.LessOrEqual(d, p.MaxDelay)
+		prev = d
+	}
+}
+
+func TestScheduleJitterBounds(t *testing.T) {
+	assert := require.New(t)
+	base := 100 * time.Millisecond
+
+	full := newSchedule(NewPolicy(WithStrategy(Constant), WithBaseDelay(base), WithJitter(FullJitter)))
+	equal := newSchedule(NewPolicy(WithStrategy(Constant), WithBaseDelay(base), WithJitter(EqualJitter)))
+
+	for i := 1; i <= 100; i++ {
+		d := full.next(i)
+		assert.GreaterOrEqual(d, time.Duration(0))
+		assert.LessOrEqual(d, base)
+
+		d = equal.next(i)
+		assert.GreaterOrEqual(d, base/2)
+		assert.LessOrEqual(d, base)
+	}
+}
+
+func TestPolicyJSON(t *testing.T) {
+	assert := require.New(t)
+
+	p := DefaultPolicy()
+	err := json.Unmarshal([]byte(`{
+		"strategy": "linear",
+		"base_delay": "250ms",
+		"jitter": "full",
+		"max_attempts": 4,
+		"max_elapsed_time": 1000000000
+	}`), &p)
+	assert.NoError(err)
+
+	assert.Equal(Linear, p.Strategy)
+	assert.Equal(250*time.Millisecond, p.BaseDelay)
+	assert.Equal(FullJitter, p.Jitter)
+	assert.Equal(4, p.MaxAttempts)
+	assert.Equal(time.Second, p.MaxElapsedTime)
+	// Fields missing from the JSON keep their value.
+	assert.Equal(5*time.Second, p.MaxDelay)
+	assert.Equal(1.5, p.Factor)
+
+	b, err := json.Marshal(p)
+	assert.NoError(err)
+
+	var roundTrip Policy
+	assert.NoError(json.Unmarshal(b, &roundTrip))
+	assert.Equal(p, roundTrip)
+}
+
+func TestPolicyJSONInvalid(t *testing.T) {
+	assert := require.New(t)
+
+	p := DefaultPolicy()
+	assert.Error(json.Unmarshal([]byte(`{"strategy": "fibonacci"}`), &p))
+	assert.Error(json.Unmarshal([]byte(`{"jitter": "some"}`), &p))
+	assert.Error(json.Unmarshal([]byte(`{"base_delay": "soon"}`), &p))
+	assert.Error(json.Unmarshal([]byte(`{"base_delay": "0s"}`), &p))
+	assert.Error(json.Unmarshal([]byte(`{"max_attempts": -1}`), &p))
+	assert.Error(json.Unmarshal([]byte(`{"factor": 0.5}`), &p))
+}
+
+func TestPolicyValidate(t *testing.T) {
+	assert := require.New(t)
+
+	assert.NoError(NewPolicy().Validate())
+	assert.Error(NewPolicy(WithBaseDelay(0)).Validate())
+	assert.Error(NewPolicy(WithInitialDelay(-time.Second)).Validate())
+	assert.Error(NewPolicy(WithMaxDelay(-time.Second)).Validate())
+	assert.Error(NewPolicy(WithMaxElapsedTime(-time.Second)).Validate())
+	assert.Error(NewPolicy(WithMaxAttempts(-1)).Validate())
+	assert.Error(NewPolicy(WithFactor(0.5)).Validate())
+}
+
+func TestRetryContextInvalidPolicy(t *testing.T) {
+	assert := require.New(t)
+
+	calls := 0
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		calls++
+		return errors.New("nope")
+	}, WithBaseDelay(0))
+
+	assert.EqualError(err, "invalid retry policy: base delay 0s is not positive")
+	assert.Equal(0, calls)
+}
+
+func TestRetryContextMaxElapsedTime(t *testing.T) {
+	assert := require.New(t)
+
+	clock := retrytest.NewClock(time.Now())
+	var iteration int
+	err := RetryContext(context.Background(), func(ctx context.Context, i int) error {
+		iteration = i
+		return errors.New("nope")
+	}, WithStrategy(Constant), WithBaseDelay(20*time.Millisecond), WithJitter(NoJitter), WithMaxElapsedTime(50*time.Millisecond),
+		WithClock(clock))
+
+	assert.ErrorIs(err, cerr.ErrRetryTimeout)
+	assert.Equal(3, iteration)
+	assert.Equal(40*time.Millisecond, clock.Elapsed())
+}
@@ -1,9 +1,11 @@
 package retry
 
 import (
+	"context"
 	"time"
 
 	"github.com/aserto-dev/go-utils/cerr"
+	"github.com/aserto-dev/go-utils/opts"
 	"github.com/jpillora/backoff"
 )
 
@@ -12,7 +14,13 @@ import (
 // It uses an exponential backoff for retries, with a min of 10ms, max of 5 seconds and a factor of 1.5.
 // Uses jitter to randomize sleep durations, to avoid contention. See more here:  github.com/jpillora/backoff
 // If the duration is set to 0, it run the given function once.
-func Retry(timeout time.Duration, f func(int) error) (err error) {
+// Errors that DefaultClassifier deems non-retryable, such as those wrapped with Permanent,
+// are returned at once, unwrapped from Permanent.
+// params can observe the loop with WithOperation, WithOnRetry, WithOnGiveUp, WithLogger,
+// WithLogSampler and WithMetrics, limit retries with WithBudget and replace the clock with WithClock.
+// Options changing the backoff only apply to RetryContext.
+func Retry(timeout time.Duration, f func(int) error, params ...opts.Param) (err error) {
+	p := NewPolicy(params...)
 	b := &backoff.Backoff{
 		Min:    10 * time.Millisecond,
 		Max:    5 * time.Second,
@@ -21,31 +29,42 @@ func Retry(timeout time.Duration, f func(int) error) (err error) {
 	}
 
 	attempt := 1
+	p.started()
 
 	if timeout == 0 {
+		p.attempted()
 		err = f(attempt)
 		if err != nil {
-			return cerr.ErrRetryTimeout.Err(err)
+			return p.gaveUp(attempt, cerr.ErrRetryTimeout.Err(err), true)
 		}
+		p.succeeded(attempt)
 		return nil
 	}
 
-retryLoop:
-	for t := time.After(timeout); ; {
-		select {
-		case <-t:
-			break retryLoop
-		default:
-		}
+	clock := p.clock()
+	deadline := clock.Now().Add(timeout)
 
+	for clock.Now().Before(deadline) {
+		p.attempted()
 		err = f(attempt)
 		if err == nil {
+			p.succeeded(attempt)
 			return
 		}
 
+		if DefaultClassifier(err) == NonRetryable {
+			return p.gaveUp(attempt, unwrapPermanent(err), false)
+		}
+
+		if !p.allowRetry() {
+			return p.gaveUp(attempt, cerr.ErrRetryTimeout.Err(&Error{Reason: ErrBudgetExhausted, Last: err, Attempts: attempt}), true)
+		}
+
+		delay := b.Duration()
+		p.retrying(attempt, err, delay)
 		attempt++
-		time.Sleep(b.Duration())
+		_ = clock.Sleep(context.Background(), delay)
 	}
 
-	return cerr.ErrRetryTimeout.Err(err)
+	return p.gaveUp(attempt-1, cerr.ErrRetryTimeout.Err(err), true)
 }
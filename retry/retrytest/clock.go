@@ -0,0 +1,71 @@
+// Package retrytest provides utilities for testing code that uses package retry.
+package retrytest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is a fake retry.Clock. Sleeping doesn't wait: it moves the clock forward by the
+// requested duration and records it, so that tests can check the delays of a retry loop.
+// It's safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewClock returns a fake clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep moves the clock forward by d and returns at once, or returns ctx.Err() if ctx is done.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+// Advance moves the clock forward by d, as if time had been spent outside of Sleep.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Sleeps returns the durations passed to Sleep, in order.
+func (c *Clock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]time.Duration{}, c.sleeps...)
+}
+
+// Elapsed returns the total time slept.
+func (c *Clock) Elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total time.Duration
+	for _, d := range c.sleeps {
+		total += d
+	}
+	return total
+}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// schedule computes the delays of a Policy. It's stateful because DecorrelatedJitter
+// depends on the previous delay, so every retry loop needs its own.
+type schedule struct {
+	policy *Policy
+	prev   time.Duration
+	rand   func() float64
+}
+
+func newSchedule(p *Policy) *schedule {
+	return &schedule{
+		policy: p,
+		prev:   p.BaseDelay,
+		rand:   rand.Float64,
+	}
+}
+
+// next returns the delay to wait after the given failed attempt, starting at 1.
+func (b *schedule) next(attempt int) time.Duration {
+	p := b.policy
+	base := float64(p.BaseDelay)
+
+	var delay float64
+	switch p.Strategy {
+	case Constant:
+		delay = base
+	case Linear:
+		delay = base * float64(attempt)
+	case DecorrelatedJitter:
+		delay = base + b.rand()*(3*float64(b.prev)-base)
+	default:
+		factor := p.Factor
+		if factor < 1 {
+			factor = 1
+		}
+		delay = base * math.Pow(factor, float64(attempt-1))
+	}
+
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if delay > math.MaxInt64 {
+		delay = math.MaxInt64
+	}
+
+	if p.Strategy == DecorrelatedJitter {
+		b.prev = time.Duration(delay)
+		return b.prev
+	}
+
+	switch p.Jitter {
+	case FullJitter:
+		delay = b.rand() * delay
+	case EqualJitter:
+		delay = delay/2 + b.rand()*delay/2
+	}
+
+	return time.Duration(delay)
+}